@@ -0,0 +1,47 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import "io"
+
+// INFO: byte slice formats
+//      Formats loaded from files or network frames arrive as []byte. Such format is parsed without copying and
+//      looked up in cache without allocating a key string (map lookup by string(b) doesn't allocate); it is copied
+//      only when a new cache entry (or repetitions counter) is inserted, because cached tokens reference format
+//      memory, which may be modified by the caller after the call
+
+// Appendf formats according to format, appends the result to dst and returns the extended slice
+func Appendf(dst []byte, format string, args ...string) []byte {
+	xfmt := forgeXfmt(format)
+	return xfmt.Append(dst, args)
+}
+
+// Appendfb is Appendf with []byte format
+// NOTE format may be modified after the call, but not while the call is in progress
+func Appendfb(dst []byte, format []byte, args ...string) []byte {
+	xfmt := forgeXfmtFrom(bytesString(format), true)
+	return xfmt.Append(dst, args)
+}
+
+// Fprintfb is Fprintf with []byte format
+// NOTE format may be modified after the call, but not while the call is in progress
+func Fprintfb(w io.Writer, format []byte, args ...string) (n int, err error) {
+	xfmt := forgeXfmtFrom(bytesString(format), true)
+	return xfmt.Fprint(w, args)
+}
@@ -0,0 +1,106 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"bytes"
+	"testing"
+)
+
+// go test -count=1 -v -run "^TestAppendf$"
+func TestAppendf(t *testing.T) {
+
+	dst := []byte("prefix: ")
+
+	if got, want := string(Appendf(dst, "%s=%q", "k", "v")), `prefix: k="v"`; got != want {
+		t.Fatalf("Appendf mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	if got, want := string(Appendf(nil, "no verbs")), "no verbs"; got != want {
+		t.Fatalf("Appendf mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	if got := Appendf(nil, ""); got != nil {
+		t.Fatalf("Appendf of empty format must return dst as is: %q", got)
+	}
+
+	// large result grows dst
+	large := string(bytes.Repeat([]byte{'x'}, 2*maxAllowedBufSize))
+
+	if got := Appendf(dst[:0], "%s|%s", large, large); string(got) != large+"|"+large {
+		t.Fatalf("Appendf mismatch large result len: %d", len(got))
+	}
+}
+
+// go test -count=1 -v -run "^TestFormatBytes$"
+func TestFormatBytes(t *testing.T) {
+
+	defer SetCacheThreshold(CacheThreshold())
+
+	for _, threshold := range [...]uint{CacheAlways, CacheRepetitions, CacheDisabled} {
+
+		purgeCaches()
+
+		SetCacheThreshold(threshold)
+
+		for i := 0; i < 3; i++ {
+
+			format := []byte("frame %s: %x|")
+
+			if got, want := string(Appendfb(nil, format, "a", "b")), "frame a: 62|"; got != want {
+				t.Fatalf("%d: Appendfb mismatch result: want <%s>, got <%s>", threshold, want, got)
+			}
+
+			var buf bytes.Buffer
+
+			if _, err := Fprintfb(&buf, format, "c", "d"); (err != nil) || (buf.String() != "frame c: 64|") {
+				t.Fatalf("%d: Fprintfb mismatch result: got <%s> (err: %v)", threshold, buf.String(), err)
+			}
+
+			// caller modifies format after the call, cached entries must not be affected
+			copy(format, "FRAME %q: %s|")
+
+			if got, want := Sprintf("frame %s: %x|", "e", "f"), "frame e: 66|"; got != want {
+				t.Fatalf("%d: cached format has been modified: want <%s>, got <%s>", threshold, want, got)
+			}
+		}
+
+		if threshold == CacheDisabled {
+			continue
+		}
+
+		// neither key nor tokens of cache entry share memory of modified formats
+		x, has := xfmtCache.Get(formatKey{format: "frame %s: %x|"})
+
+		if !has {
+			t.Fatalf("%d: cache entry is absent", threshold)
+		}
+
+		if got, want := x.Sprint([]string{"g", "h"}), "frame g: 68|"; got != want {
+			t.Fatalf("%d: cache entry mismatch result: want <%s>, got <%s>", threshold, want, got)
+		}
+
+		format, dst := []byte("frame %s: %x|"), make([]byte, 0, 64)
+
+		assertMallocs(t, "Appendfb/Fprintfb", 0, func() {
+			dst = Appendfb(dst[:0], format, "a", "b")
+			_, _ = Fprintfb(mallocWriter{}, format, "a", "b")
+		})
+	}
+}
@@ -0,0 +1,85 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import "unicode/utf8"
+
+// INFO: self-rendering values
+//      Without `reflect` user types can't participate in formatting except by pre-rendering them to strings.
+//      Types implementing Appender (IDs, IP addresses, durations, etc.) are passed as Value args of the *A fns and
+//      append their representation directly to the pooled buffer, so no intermediate allocations are required.
+//      Width is applied by xfmt to the appended representation afterwards (like `pad` does for other values),
+//      while precision and flags are interpreted by the Appender itself
+
+// Flags are format flags passed to Appender
+type Flags uint16
+
+const (
+	FlagPlus  = Flags(flagPlus)  // '+'
+	FlagMinus = Flags(flagMinus) // '-'
+	FlagSharp = Flags(flagSharp) // '#'
+	FlagSpace = Flags(flagSpace) // ' '
+	FlagZero  = Flags(flagZero)  // '0'
+
+	// all the public flags
+	flagsPublic = flagPlus | flagMinus | flagSharp | flagSpace | flagZero
+)
+
+// Has reports whether flag is set
+// inlined
+//go:nosplit
+func (f Flags) Has(flag Flags) bool {
+	return (f & flag) != 0
+}
+
+// Appender is implemented by values, which render themselves
+type Appender interface {
+	// AppendXfmt appends representation of the value for verb (any, even unknown for xfmt) to dst and returns
+	// the extended slice; width (absentValue -1 if absent) is for info only, since padding is applied afterwards
+	// by xfmt, while prec (-1 if absent) and flags should be interpreted by the value itself
+	AppendXfmt(dst []byte, verb rune, flags Flags, width, prec int) []byte
+}
+
+// Value returns Arg of value, which renders itself
+// inlined
+//go:nosplit
+func Value(a Appender) Arg {
+
+	if a == nil {
+		return Arg{kind: argNil}
+	}
+
+	return Arg{v: a, kind: argAppender}
+}
+
+// fmtAppender appends the value's representation directly to the buffer or, if it should be padded, to the temp
+// buffer and pads it the same way as other values
+// SEE padCount()
+//go:nosplit
+func (token *token) fmtAppender(buf *buffer, a Appender, flags flags, width, prec int) {
+
+	verb, _ := utf8.DecodeRuneInString(token.verbString())
+
+	if width <= 0 /* implies `width == absentValue` */ {
+		buf.buf = a.AppendXfmt(buf.buf, verb, Flags(flags&flagsPublic), width, prec)
+		return
+	}
+
+	padCount(buf, a.AppendXfmt(buf.TempBuf(), verb, Flags(flags&flagsPublic), width, prec), absentValue, flags, width)
+}
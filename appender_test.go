@@ -0,0 +1,115 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"strconv"
+	"testing"
+)
+
+type testIPv4 [4]byte
+
+func (ip testIPv4) AppendXfmt(dst []byte, verb rune, flags Flags, width, prec int) []byte {
+
+	if verb == 'x' || verb == 'X' {
+
+		if flags.Has(FlagSharp) {
+			dst = append(dst, '0', byte(verb))
+		}
+
+		for _, b := range ip {
+			dst = append(dst, ldigits[b>>4], ldigits[b&0x0F])
+		}
+
+		return dst
+	}
+
+	// the rest of verbs are dotted form
+	for i, b := range ip {
+
+		if i > 0 {
+			dst = append(dst, '.')
+		}
+
+		dst = strconv.AppendUint(dst, uint64(b), 10)
+	}
+
+	return dst
+}
+
+// go test -count=1 -v -run "^TestAppenderArgs$"
+func TestAppenderArgs(t *testing.T) {
+
+	ip := Value(testIPv4{10, 0, 0, 1})
+
+	cases := [...]struct {
+		format string
+		args   []Arg
+		want   string
+	}{
+		{"%s", []Arg{ip}, "10.0.0.1"},
+		{"%v", []Arg{ip}, "10.0.0.1"},
+		{"%#x", []Arg{ip}, "0x0a000001"},
+		{"%X", []Arg{ip}, "0a000001"},
+		{"[%12s]", []Arg{ip}, "[    10.0.0.1]"},
+		{"[%-12s]", []Arg{ip}, "[10.0.0.1    ]"},
+		{"[%012s]", []Arg{ip}, "[000010.0.0.1]"},
+		{"[%*s]", []Arg{Int(10), ip}, "[  10.0.0.1]"},
+		{"[%3s]", []Arg{ip}, "[10.0.0.1]"},
+		{"addr=%s:%d", []Arg{ip, Int(80)}, "addr=10.0.0.1:80"},
+		{"%s", []Arg{Value(nil)}, "%!s(<nil>)"},
+		{"%s", []Arg{Str("a"), ip}, "a%!(EXTRA xfmt.Appender=10.0.0.1)"},
+	}
+
+	for _, c := range cases {
+		if got := SprintfA(c.format, c.args...); got != c.want {
+			t.Errorf("%q: mismatch result: want <%s>, got <%s>", c.format, c.want, got)
+		}
+	}
+
+	// left padding of the appended value also works in appending mode
+	if got, want := string(AppendfA([]byte("ip: "), "%10s", ip)), "ip:   10.0.0.1"; got != want {
+		t.Errorf("AppendfA mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	// fill and centering go through the same padding as other values
+	token := token{value: "s", verb: verbString}
+
+	for _, c := range [...]struct {
+		flags flags
+		want  string
+	}{
+		{flagCenter.withFill('*'), "*10.0.0.1**"},
+		{flagMinus.withFill('-'), "10.0.0.1---"},
+		{flags(0).withFill('.'), "...10.0.0.1"},
+	} {
+
+		var buf buffer
+
+		token.fmtAppender(&buf, ip.v.(Appender), c.flags, 11, absentValue)
+
+		if got := buf.String(); got != c.want {
+			t.Errorf("%#x: mismatch padded result: want <%s>, got <%s>", uint16(c.flags), c.want, got)
+		}
+	}
+
+	assertMallocs(t, "FprintfA of Appender", 0, func() {
+		_, _ = FprintfA(mallocWriter{}, "peer %20s connected", ip)
+	})
+}
@@ -0,0 +1,820 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"errors"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"unicode/utf8"
+)
+
+// INFO: typed args
+//      Arg is a small tagged union of the most common arg types, so numbers and bools may be formatted without
+//      `strconv` calls by the caller, and still without `reflect` and interface boxing. Numeric verbs are
+//      implemented via strconv.Append* into buffer.TempBuf(), flags, width and precision are handled the same way
+//      as std `fmt` handles them
+
+type argKind uint8
+
+const (
+	argString argKind = iota
+	argInt
+	argUint
+	argFloat
+	argFloat32
+	argBool
+	argNil
+	argAppender    // SEE appender.go
+	argError       // SEE methods.go
+	argStringer    // SEE methods.go
+	argUnsupported // SEE methods.go
+)
+
+// names of arg types for error marks
+var argTypeNames = [...]string{
+	argString:      reflectStringType,
+	argInt:         reflectInt64Type,
+	argUint:        reflectUint64Type,
+	argFloat:       reflectFloatType,
+	argFloat32:     reflectFloat32Type,
+	argBool:        reflectBoolType,
+	argNil:         nilAngleString,
+	argAppender:    appenderType,
+	argError:       errorType,
+	argStringer:    stringerType,
+	argUnsupported: unsupportedType,
+}
+
+// Arg is a typed arg value for the *A fns (SprintfA, FprintfA, etc.)
+// NOTE zero value is an empty string
+type Arg struct {
+	s    string      // string value
+	u    uint64      // bits of int, uint, float or bool value
+	v    interface{} // value of interface kinds (Appender, error, Stringer, etc.)
+	kind argKind
+}
+
+// Str returns Arg of string value
+// inlined
+//go:nosplit
+func Str(s string) Arg {
+	return Arg{s: s, kind: argString}
+}
+
+// Bytes returns Arg of string value sharing backing storage of `b` without copying
+// WARN `b` must not be modified while Arg is in use
+// inlined
+//go:nosplit
+func Bytes(b []byte) Arg {
+	return Arg{s: bytesString(b), kind: argString}
+}
+
+// Int returns Arg of signed integer value
+// inlined
+//go:nosplit
+func Int(v int64) Arg {
+	return Arg{u: uint64(v), kind: argInt}
+}
+
+// Uint returns Arg of unsigned integer value
+// inlined
+//go:nosplit
+func Uint(v uint64) Arg {
+	return Arg{u: v, kind: argUint}
+}
+
+// Float returns Arg of floating-point value
+// inlined
+//go:nosplit
+func Float(v float64) Arg {
+	return Arg{u: math.Float64bits(v), kind: argFloat}
+}
+
+// Bool returns Arg of bool value
+// inlined
+//go:nosplit
+func Bool(v bool) Arg {
+
+	a := Arg{kind: argBool}
+
+	if v {
+		a.u = 1
+	}
+
+	return a
+}
+
+// appendValue appends arg value in default format (%v) to b
+//go:nosplit
+func (arg *Arg) appendValue(b []byte) []byte {
+
+	switch arg.kind {
+	case argInt:
+		return strconv.AppendInt(b, int64(arg.u), 10)
+	case argUint:
+		return strconv.AppendUint(b, arg.u, 10)
+	case argFloat:
+		return strconv.AppendFloat(b, math.Float64frombits(arg.u), 'g', -1, 64)
+	case argFloat32:
+		return strconv.AppendFloat(b, math.Float64frombits(arg.u), 'g', -1, 32)
+	case argBool:
+		return strconv.AppendBool(b, arg.u != 0)
+	case argNil:
+		return append(b, nilAngleString...)
+	case argAppender:
+		return arg.v.(Appender).AppendXfmt(b, verbCharValue, 0, absentValue, absentValue)
+	case argError:
+		return append(b, arg.v.(error).Error()...)
+	case argStringer:
+		return append(b, arg.v.(stringer).String()...)
+	case argUnsupported:
+		return append(b, unsupportedType...)
+	}
+
+	return append(b, arg.s...)
+}
+
+// intValue returns integer value of arg for indirect width and precision
+// SEE src/fmt/print.go::intFromArg()
+//go:nosplit
+func (arg *Arg) intValue() (n int, ok bool) {
+
+	switch arg.kind {
+	case argInt:
+		n, ok = int(int64(arg.u)), int64(int(int64(arg.u))) == int64(arg.u)
+	case argUint:
+		n, ok = int(arg.u), (int(arg.u) >= 0) && (uint64(int(arg.u)) == arg.u)
+	}
+
+	// SEE src/fmt/print.go::tooLarge()
+	if ok && ((n > maxNum) || (n < -maxNum)) {
+		return 0, false
+	}
+
+	return n, ok
+}
+
+// SEE (*token).badVerb()
+func (token *token) badVerbA(buf *buffer, arg *Arg) {
+
+	if arg.kind == argString {
+		token.badVerb(buf, arg.s)
+		return
+	}
+
+	// SEE src/fmt/print.go::(*pp).badVerb() `case p.arg != nil` ... `default`
+	if arg.kind == argNil {
+		buf.WriteString(percentBangString)
+		buf.WriteString(token.verbString())
+		buf.WriteString(nilParenString)
+		return
+	}
+
+	buf.WriteString(percentBangString)
+	buf.WriteString(token.verbString())
+
+	buf.WriteByte(charLeftParens) // (
+
+	buf.WriteString(argTypeNames[arg.kind])
+	buf.WriteByte(charEquals) // =
+	arg.writeValue(buf)
+
+	buf.WriteByte(charRightParens) // )
+}
+
+// formatA is (*token).format() for typed args
+// SEE (*token).format()
+// @return bool success status
+func (token *token) formatA(buf *buffer, args []Arg) bool {
+
+	// simple case - solely raw string const value
+	if token.verb == verbNone {
+		buf.WriteString(token.value)
+		return true
+	}
+
+	flags := token.flags // clone flags for possible inplace modifications
+
+	badArgNum := false
+
+	width := int(token.width)
+
+	// is width indirect?
+	if flags.has(flagIndirectWidth) {
+
+		ok := false
+
+		// check is width arg exist
+		if uint(width) >= uint(len(args)) {
+			badArgNum = true
+		} else {
+			width, ok = args[width].intValue()
+		}
+
+		if !ok {
+			buf.WriteString(badWidthString)
+			width = absentValue
+		} else if width < 0 {
+			// negative width means padding on the right (and never with zeros)
+			width = -width
+			flags = (flags | flagPadRight) &^ flagPadZeros
+		}
+	}
+
+	prec := int(token.prec)
+
+	// is precision indirect?
+	if flags.has(flagIndirectPrec) {
+
+		ok := false
+
+		// check is prec arg exist
+		if uint(prec) >= uint(len(args)) {
+			badArgNum = true
+		} else {
+			prec, ok = args[prec].intValue()
+		}
+
+		// negative precision doesn't make sense
+		if !ok || (prec < 0) {
+			buf.WriteString(badPrecString)
+			prec = absentValue
+		}
+	}
+
+	// if arg num of either width or prec out of bounds
+	if badArgNum {
+		token.badArgNum(buf)
+		return false
+	}
+
+	// check that appropriate arg exists in args
+	if uint(token.arg) >= uint(len(args)) {
+		token.missingArg(buf)
+		return false
+	}
+
+	// pick our arg, empty one is replaced by default value
+	arg := &args[token.arg]
+
+	if arg.empty() && token.hasDefault() {
+		def := Str(token.defaultValue())
+		arg = &def
+	}
+
+	// appender handles any verb (even unknown one) by itself
+	if arg.kind == argAppender {
+		token.fmtAppender(buf, arg.v.(Appender), flags, width, prec)
+		return true
+	}
+
+	if token.verb == badVerb {
+		token.badVerbA(buf, arg)
+		return false
+	}
+
+	switch arg.kind {
+	case argString:
+		return token.fmtString(buf, arg.s, flags, width, prec)
+	case argInt, argUint:
+		return token.fmtIntegerA(buf, arg, flags, width, prec)
+	case argFloat, argFloat32:
+		return token.fmtFloatA(buf, arg, flags, width, prec)
+	case argError, argStringer:
+		return token.fmtMethod(buf, arg, flags, width, prec)
+	case argNil:
+		// SEE src/fmt/print.go::(*pp).printArg() `case nil`
+		if token.verb == verbValue {
+			padString(buf, nilAngleString, flags, width)
+			return true
+		}
+	case argBool:
+		if (token.verb == verbBool) || (token.verb == verbValue) {
+			if arg.u != 0 {
+				padString(buf, strconv.FormatBool(true), flags, width)
+			} else {
+				padString(buf, strconv.FormatBool(false), flags, width)
+			}
+
+			return true
+		}
+	}
+
+	token.badVerbA(buf, arg)
+
+	return false
+}
+
+// SEE src/fmt/print.go::(*pp).fmtInteger()
+//go:nosplit
+func (token *token) fmtIntegerA(buf *buffer, arg *Arg, flags flags, width, prec int) bool {
+
+	base := 0
+
+	switch token.verb {
+	case verbValue:
+		// %#v of unsigned is hex with leading 0x, and there is no sign for %+v
+		// SEE src/fmt/print.go::(*pp).fmtInteger() and (*pp).doPrintf() `case verb == 'v'`
+		if flags.has(flagAltFmt) && (arg.kind == argUint) {
+			base, flags = 16, flags&^(flagPlus|flagUpperVerb)
+		} else {
+			base, flags = 10, flags&^(flagPlus|flagSharp)
+		}
+	case verbDecimal:
+		base = 10
+	case verbHex:
+		base = 16
+	case verbOctal:
+		base = 8
+	case verbBinary:
+		base = 2
+	case verbQuoted:
+		fmtQuotRune(buf, arg.u, flags, width)
+		return true
+	case verbRune:
+		fmtRune(buf, arg.u, flags, width)
+		return true
+	default:
+		token.badVerbA(buf, arg)
+		return false
+	}
+
+	fmtInteger(buf, arg.u, arg.kind == argInt, base, flags, width, prec)
+
+	return true
+}
+
+// SEE src/fmt/format.go::(*fmt).fmtQc()
+//go:nosplit
+func fmtQuotRune(buf *buffer, u uint64, flags flags, width int) {
+
+	r := rune(u)
+
+	if u > utf8.MaxRune {
+		r = utf8.RuneError
+	}
+
+	if flags.has(flagAsciiOnly) {
+		pad(buf, strconv.AppendQuoteRuneToASCII(buf.TempBuf(), r), flags, width)
+	} else {
+		pad(buf, strconv.AppendQuoteRune(buf.TempBuf(), r), flags, width)
+	}
+}
+
+// SEE src/fmt/format.go::(*fmt).fmtC()
+//go:nosplit
+func fmtRune(buf *buffer, u uint64, flags flags, width int) {
+
+	r := rune(u)
+
+	// invalid code points (including surrogates) are written by utf8.AppendRune as RuneError too
+	if u > utf8.MaxRune {
+		r = utf8.RuneError
+	}
+
+	pad(buf, utf8.AppendRune(buf.TempBuf(), r), flags, width)
+}
+
+// SEE src/fmt/format.go::(*fmt).fmtInteger()
+//go:nosplit
+func fmtInteger(buf *buffer, u uint64, isSigned bool, base int, flags flags, width, prec int) {
+
+	negative := isSigned && (int64(u) < 0)
+
+	if negative {
+		u = -u
+	}
+
+	// Two ways to ask for extra leading zero digits: %.3d or %03d. If both are specified the flagPadZeros is
+	// ignored and padding with spaces is used instead.
+	if prec != absentValue {
+		// Precision of 0 and value of 0 means "print nothing" but padding.
+		if (prec == 0) && (u == 0) {
+			writePadding(buf, width, flags&^flagPadZeros)
+			return
+		}
+	} else if flags.has(flagPadZeros) && (width > 0) /* zero padding is allowed only to the left */ {
+
+		prec = width
+
+		if negative || flags.has(flagSign|flagSpace) {
+			prec-- // leave room for sign
+		}
+	}
+
+	digits := strconv.AppendUint(buf.TempBuf(), u, base)
+
+	if (base == 16) && flags.has(flagUpperVerb) {
+		for i, c := range digits {
+			if c >= 'a' {
+				digits[i] = c - ('a' - 'A')
+			}
+		}
+	}
+
+	zeros := 0
+
+	if prec > len(digits) {
+		zeros = prec - len(digits)
+	}
+
+	// Various prefixes: 0x, -, etc. (in reverse order)
+	var (
+		prefixBuf [5]byte
+		i         = len(prefixBuf)
+	)
+
+	if flags.has(flagAltFmt) {
+		switch base {
+		case 2:
+			// Add a leading 0b.
+			i -= 2
+			prefixBuf[i], prefixBuf[i+1] = charZero, 'b'
+		case 8:
+			if (zeros == 0) && (digits[0] != charZero) {
+				i--
+				prefixBuf[i] = charZero
+			}
+		case 16:
+			// Add a leading 0x or 0X.
+			digitX := ldigits[16]
+
+			if flags.has(flagUpperVerb) {
+				digitX = udigits[16]
+			}
+
+			i -= 2
+			prefixBuf[i], prefixBuf[i+1] = charZero, digitX
+		}
+	}
+
+	// %O always has leading 0o
+	if (base == 8) && flags.has(flagUpperVerb) {
+		i -= 2
+		prefixBuf[i], prefixBuf[i+1] = charZero, 'o'
+	}
+
+	if negative {
+		i--
+		prefixBuf[i] = '-'
+	} else if flags.has(flagSign) {
+		i--
+		prefixBuf[i] = '+'
+	} else if flags.has(flagSpace) {
+		i--
+		prefixBuf[i] = charSpace
+	}
+
+	prefix := prefixBuf[i:]
+
+	// Left padding with zeros has already been handled like precision earlier or the flagPadZeros is ignored due
+	// to an explicitly set precision.
+	width -= len(prefix) + zeros + len(digits)
+
+	rpad := flags.has(flagPadRight)
+
+	if !rpad && (width > 0) {
+		writePadding(buf, width, flagNone)
+	}
+
+	buf.Write(prefix)
+	writePadding(buf, zeros, flagPadZeros)
+	buf.Write(digits)
+
+	if rpad && (width > 0) {
+		writePadding(buf, width, flagNone)
+	}
+}
+
+// SEE src/fmt/print.go::(*pp).fmtFloat()
+//go:nosplit
+func (token *token) fmtFloatA(buf *buffer, arg *Arg, flags flags, width, prec int) bool {
+
+	upper := flags.has(flagUpperVerb)
+
+	var fmtByte byte
+
+	switch token.verb {
+	case verbValue:
+		// there is neither sign for %+v nor alt format for %#v
+		fmtByte, flags = verbCharGeneral, flags&^(flagPlus|flagSharp)
+	case verbBinary:
+		fmtByte = verbCharBinary
+	case verbGeneral:
+		fmtByte = verbCharGeneral
+	case verbHex:
+		fmtByte = verbCharHex
+	case verbExp:
+		fmtByte = verbCharExp
+	case verbFloat:
+		// %F is the same as %f
+		fmtByte, upper = verbCharFloat, false
+	default:
+		token.badVerbA(buf, arg)
+		return false
+	}
+
+	if upper {
+		fmtByte -= 'a' - 'A'
+	}
+
+	// default precisions
+	if prec == absentValue {
+		switch token.verb {
+		case verbExp, verbFloat:
+			prec = 6
+		}
+	}
+
+	bitSize := 64
+
+	if arg.kind == argFloat32 {
+		bitSize = 32
+	}
+
+	fmtFloat(buf, math.Float64frombits(arg.u), fmtByte, bitSize, flags, width, prec)
+
+	return true
+}
+
+// SEE src/fmt/format.go::(*fmt).fmtFloat()
+//go:nosplit
+func fmtFloat(buf *buffer, v float64, verb byte, bitSize int, flags flags, width, prec int) {
+
+	// Format number, reserving space for leading + sign if needed.
+	num := strconv.AppendFloat(buf.TempBuf()[:1], v, verb, prec, bitSize)
+
+	if (num[1] == '-') || (num[1] == '+') {
+		num = num[1:]
+	} else {
+		num[0] = '+'
+	}
+
+	// flagSpace means to add a leading space instead of a "+" sign unless the sign is explicitly asked for by
+	// flagSign.
+	if flags.has(flagSpace) && (num[0] == '+') && flags.omit(flagSign) {
+		num[0] = charSpace
+	}
+
+	// Special handling for infinities and NaN, which don't look like a number so shouldn't be padded with zeros.
+	if (num[1] == 'I') || (num[1] == 'N') {
+
+		// Remove sign before NaN if not asked for.
+		if (num[1] == 'N') && flags.omit(flagSpace|flagSign) {
+			num = num[1:]
+		}
+
+		padCount(buf, num, len(num), flags&^flagPadZeros, width)
+
+		return
+	}
+
+	// The sharp flag forces printing a decimal point for non-binary formats and retains trailing zeros, which we
+	// may need to restore.
+	if flags.has(flagAltFmt) && (verb != verbCharBinary) {
+
+		digits := 0
+
+		switch verb {
+		case verbCharGeneral, verbCharGeneral - ('a' - 'A'), verbCharHex:
+			digits = prec
+			// If no precision is set explicitly use a precision of 6.
+			if digits == absentValue {
+				digits = 6
+			}
+		}
+
+		// Buffer pre-allocated with enough room for exponent notations of the form "e+123" or "p-1023".
+		var tailBuf [6]byte
+
+		tail := tailBuf[:0]
+
+		hasDecimalPoint, sawNonzeroDigit := false, false
+
+		// Starting from i = 1 to skip sign at num[0].
+		for i := 1; i < len(num); i++ {
+			switch num[i] {
+			case '.':
+				hasDecimalPoint = true
+			case 'p', 'P':
+				tail = append(tail, num[i:]...)
+				num = num[:i]
+			case 'e', 'E':
+				if (verb != verbCharHex) && (verb != verbCharHex-('a'-'A')) {
+					tail = append(tail, num[i:]...)
+					num = num[:i]
+					break
+				}
+				fallthrough
+			default:
+				if num[i] != charZero {
+					sawNonzeroDigit = true
+				}
+				// Count significant digits after the first non-zero digit.
+				if sawNonzeroDigit {
+					digits--
+				}
+			}
+		}
+
+		if !hasDecimalPoint {
+			// Leading digit 0 should contribute once to digits.
+			if (len(num) == 2) && (num[1] == charZero) {
+				digits--
+			}
+
+			num = append(num, '.')
+		}
+
+		for ; digits > 0; digits-- {
+			num = append(num, charZero)
+		}
+
+		num = append(num, tail...)
+	}
+
+	// We want a sign if asked for and if the sign is not positive.
+	if flags.has(flagSign) || (num[0] != '+') {
+
+		// If we're zero padding to the left we want the sign before the leading zeros. Achieve this by writing the
+		// sign out and then padding the unsigned number.
+		if flags.has(flagPadZeros) && (width > len(num)) {
+			buf.WriteByte(num[0])
+			writePadding(buf, width-len(num), flags)
+			buf.Write(num[1:])
+			return
+		}
+
+		padCount(buf, num, len(num), flags, width)
+
+		return
+	}
+
+	// No sign to show and the number is positive; just print the unsigned number.
+	padCount(buf, num[1:], len(num)-1, flags, width)
+}
+
+// bprintA is (*xfmt).bprint() for typed args
+// SEE (*xfmt).bprint()
+func (fmt *xfmt) bprintA(args []Arg) (buf *buffer) {
+
+	buf = fmtprintbufpool.Get()
+
+	fmt.bprintATo(buf, args)
+
+	return buf
+}
+
+// SEE (*xfmt).bprintTo()
+func (fmt *xfmt) bprintATo(buf *buffer, args []Arg) {
+
+	// try to minimize memallocs
+	buf.Grow(fmt.minSize)
+
+	for i := 0; i < len(fmt.tokens); i++ {
+
+		if fmt.tokens[i].verb == verbGroup {
+			i += skipGroupA(fmt.tokens, i, args)
+			continue
+		}
+
+		fmt.tokens[i].formatA(buf, args)
+	}
+
+	if uint(len(args)) > fmt.args {
+		writeExtraA(buf, args, fmt.args)
+	}
+}
+
+// SEE (*xfmt).Append()
+func (fmt *xfmt) AppendA(dst []byte, args []Arg) []byte {
+
+	// fast-paths
+	// - format is empty string and no args
+	if (len(fmt.tokens) == 0) && (len(args) == 0) {
+		return dst
+	}
+
+	buf := fmtprintbufpool.Get()
+
+	// temporarily replace pooled bakary by dst, so result is written directly to dst without copying
+	own := buf.buf
+	buf.buf = dst
+
+	fmt.bprintATo(buf, args)
+
+	dst, buf.buf = buf.buf, own
+
+	buf.Free()
+
+	return dst
+}
+
+// SEE writeExtra()
+func writeExtraA(buf *buffer, args []Arg, from uint) {
+
+	buf.WriteString(extraString)
+
+	for i := from; i < uint(len(args)); i++ {
+
+		if i > from {
+			buf.WriteString(commaSpaceString)
+		}
+
+		arg := &args[i]
+
+		// SEE src/fmt/print.go::(*pp).doPrintf() `if arg == nil`
+		if arg.kind == argNil {
+			buf.WriteString(nilAngleString)
+			continue
+		}
+
+		buf.WriteString(argTypeNames[arg.kind])
+		buf.WriteString(equalsStr)
+		arg.writeValue(buf)
+	}
+
+	buf.WriteString(rightParensStr)
+}
+
+func (fmt *xfmt) FprintA(w io.Writer, args []Arg) (n int, err error) {
+
+	// fast-paths
+	// - format is empty string and no args
+	if (len(fmt.tokens) == 0) && (len(args) == 0) {
+		return 0, nil
+	}
+
+	b := fmt.bprintA(args)
+
+	// WARN write buf BEFORE return it to pool
+	if b.Len() > 0 {
+		n, err = w.Write(b.Bytes())
+	}
+
+	b.Free()
+
+	return n, err
+}
+
+func (fmt *xfmt) SprintA(args []Arg) (s string) {
+
+	// fast-paths
+	// - format is empty string and no args
+	if (len(fmt.tokens) == 0) && (len(args) == 0) {
+		return ""
+	}
+
+	b := fmt.bprintA(args)
+
+	// WARN make string from buf BEFORE return buf to pool
+	s = b.String()
+
+	b.Free()
+
+	return s
+}
+
+func FprintfA(w io.Writer, format string, args ...Arg) (n int, err error) {
+	xfmt := forgeXfmt(format)
+	return xfmt.FprintA(w, args)
+}
+
+func PrintfA(format string, args ...Arg) (n int, err error) {
+	xfmt := forgeXfmt(format)
+	return xfmt.FprintA(os.Stdout, args)
+}
+
+func SprintfA(format string, args ...Arg) string {
+	xfmt := forgeXfmt(format)
+	return xfmt.SprintA(args)
+}
+
+func AppendfA(dst []byte, format string, args ...Arg) []byte {
+	xfmt := forgeXfmt(format)
+	return xfmt.AppendA(dst, args)
+}
+
+func ErrorfA(format string, args ...Arg) error {
+	xfmt := forgeXfmt(format)
+	return errors.New(xfmt.SprintA(args))
+}
@@ -0,0 +1,146 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// go test -count=1 -v -run "^TestSprintfAWithFmtPkg$"
+func TestSprintfAWithFmtPkg(t *testing.T) {
+
+	ints := [...]int64{0, 1, -1, 7, 42, -42, 255, 1000000, -1 << 63, 1<<63 - 1}
+	uints := [...]uint64{0, 1, 8, 255, 1 << 63, 1<<64 - 1}
+	floats := [...]float64{0, 1, -1, 0.5, 3.14159265, -2.5e-10, 1e21, 123456789, math.Inf(1), math.Inf(-1),
+		math.NaN(), math.Copysign(0, -1)}
+	bools := [...]bool{true, false}
+	strs := [...]string{"", "abc", "Привет"}
+
+	formats := [...]string{
+		"%d", "%5d", "%-5d|", "%05d", "%+d", "% d", "%+05d", "%.3d", "%8.3d", "%.0d", "%5.0d|", "%-08d|",
+		"%x", "%X", "%#x", "%#X", "%08x", "%#08x", "% x", "%o", "%#o", "%O", "%#O", "%b", "%#b", "%q", "%+q",
+		"%c", "%5c", "%-5c|", "%05c",
+		"%e", "%E", "%.2e", "%f", "%F", "%.3f", "%10.2f", "%-10.2f|", "%010.2f", "%+f", "% f", "%+010.1f",
+		"%g", "%G", "%.3g", "%#g", "%#.3g", "%#e", "%#.0f", "%#x", "%b", "%08g",
+		"%t", "%6t", "%-6t|",
+		"%s", "%10s", "%.2s",
+		"%v", "%+v", "%#v", "%10v", "%-10v|", "%.2v",
+		"%d %d", "%[2]d %[1]d", "%*d", "%-*d|", "%.*f", "%*.*f",
+	}
+
+	check := func(format string, a Arg, v interface{}) {
+
+		// width/prec formats take two or three args
+		args, iargs := []Arg{a, a}, []interface{}{v, v}
+
+		want, got := fmt.Sprintf(format, iargs...), SprintfA(format, args...)
+
+		// error marks may differ (e.g. bad verb value isn't padded), so compare only up to the mark
+		if i := strings.Index(want, percentBangString); (i >= 0) && strings.HasPrefix(got, want[:i+len(percentBangString)]) {
+			return
+		}
+
+		if got != want {
+			t.Errorf("%q %#v: mismatch result: want <%s>, got <%s>", format, v, want, got)
+		}
+	}
+
+	for _, format := range formats {
+
+		for _, v := range ints {
+			check(format, Int(v), v)
+		}
+
+		for _, v := range uints {
+			check(format, Uint(v), v)
+		}
+
+		for _, v := range floats {
+			check(format, Float(v), v)
+		}
+
+		for _, v := range bools {
+			check(format, Bool(v), v)
+		}
+
+		for _, v := range strs {
+			check(format, Str(v), v)
+			check(format, Bytes([]byte(v)), v)
+		}
+	}
+
+	assertMallocs(t, "FprintfA", 0, func() {
+		_, _ = FprintfA(mallocWriter{}, "%s: %d items, %.2f%% done, ok=%t, id=%#x", Str("job"), Int(42), Float(99.5),
+			Bool(true), Uint(0xdead))
+	})
+}
+
+// go test -count=1 -v -run "^TestSprintfAIndirect$"
+func TestSprintfAIndirect(t *testing.T) {
+
+	cases := [...]struct {
+		format string
+		args   []Arg
+		iargs  []interface{}
+	}{
+		{"%*s|", []Arg{Int(6), Str("ab")}, []interface{}{int64(6), "ab"}},
+		{"%*s|", []Arg{Int(-6), Str("ab")}, []interface{}{int64(-6), "ab"}},
+		{"%*d|", []Arg{Uint(4), Int(7)}, []interface{}{uint64(4), int64(7)}},
+		{"%.*s|", []Arg{Int(1), Str("ab")}, []interface{}{int64(1), "ab"}},
+		{"%.*s|", []Arg{Int(-1), Str("ab")}, []interface{}{int64(-1), "ab"}},
+		{"%*s|", []Arg{Str("x"), Str("ab")}, []interface{}{"x", "ab"}},
+		{"%*s|", []Arg{Int(10000000), Str("ab")}, []interface{}{int64(10000000), "ab"}},
+		{"%d %d", []Arg{Int(1)}, []interface{}{int64(1)}},
+		{"%d", []Arg{Int(1), Int(2), Float(2.5), Bool(true), Str("s")},
+			[]interface{}{int64(1), int64(2), 2.5, true, "s"}},
+	}
+
+	for _, c := range cases {
+		if want, got := fmt.Sprintf(c.format, c.iargs...), SprintfA(c.format, c.args...); got != want {
+			t.Errorf("%q %v: mismatch result: want <%s>, got <%s>", c.format, c.iargs, want, got)
+		}
+	}
+}
+
+type mallocWriter struct{}
+
+func (mallocWriter) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+// go test -count=1 -run "^$" -bench "^BenchmarkSprintfA$" -benchmem
+func BenchmarkSprintfA(b *testing.B) {
+
+	const format = "%s: %d items, %.2f%% done, ok=%t"
+
+	b.Run("xfmt", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = SprintfA(format, Str("job"), Int(int64(i)), Float(99.5), Bool(true))
+		}
+	})
+
+	b.Run("fmt", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = fmt.Sprintf(format, "job", i, 99.5, true)
+		}
+	})
+}
@@ -0,0 +1,376 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// INFO: brace formats
+//      Python/.NET/Rust style formats "user {} logged in from {0}", "{name:>10}" are parsed by separate frontend
+//      into the same tokens, so they share rendering engine with printf-like formats. The replacement field is
+//
+//          "{" [arg_id] ["," alignment] [":" [[fill]align][sign]["#"]["0"][width]["." precision][type]] "}"
+//
+//      where `arg_id` is either empty (next auto arg), 0-based arg index or name (SEE named.go), `alignment` is .NET
+//      signed width (negative one pads on the right), `align` is one of "<", ">" and "^" (centered), `fill` is ascii
+//      padding char, `sign` is "+", "-" or " " and `type` is printf verb char ("v" if absent). Like in python and
+//      rust values are aligned to the left by default, "0" flag pads with zeros on the left like printf "%05s"
+//      or fills explicit alignment with zeros like python
+//      "{{" and "}}" are escaped braces
+
+const (
+	charAlignLeft   = '<'
+	charAlignRight  = '>'
+	charAlignCenter = '^'
+	charAlignment   = ','
+	charSpec        = ':'
+	charSignMinus   = '-'
+
+	badSpecString = "%!(BADSPEC)"
+)
+
+var tokenErrBadSpec = token{
+	verb:  verbNone,
+	value: badSpecString,
+}
+
+var braceFrontend = frontend{
+	syntax: syntaxBrace,
+	parse:  parseBraceFormat,
+}
+
+// NOTE retval by value
+func parseBraceFormat(format string) xfmt {
+
+	needArgs, nextArg, minSize := 0, 0, 0
+
+	var (
+		tokens []token
+		lit    literal
+		names  []string
+	)
+
+	// every field is surrounded by raw const string values at most
+	if fields := strings.Count(format, "{"); fields > 0 {
+		tokens = make([]token, 0, 2*fields+1)
+	}
+
+	for pos := 0; pos < len(format); {
+
+		i := strings.IndexAny(format[pos:], "{}")
+
+		// if brace not found - grab all existing tail
+		if i == -1 {
+			i = len(format) - pos
+		}
+
+		if i > 0 {
+
+			tokens = appendLiteral(tokens, &lit, format, pos, pos+i)
+
+			minSize += i
+
+			if pos += i; pos == len(format) {
+				break
+			}
+		}
+
+		// here format[pos] is brace
+
+		c := format[pos]
+
+		// escaped "{{" or "}}" is a single brace merged with adjacent raw const string values like "%%"
+		if (pos+1 < len(format)) && (format[pos+1] == c) {
+
+			tokens = appendPercent(tokens, &lit, format, pos, 1)
+
+			minSize++
+			pos += 2
+
+			continue
+		}
+
+		// unpaired closing brace is just a char
+		if c == charCloseName {
+
+			tokens = appendLiteral(tokens, &lit, format, pos, pos+1)
+
+			minSize++
+			pos++
+
+			continue
+		}
+
+		end := strings.IndexByte(format[pos+1:], charCloseName)
+
+		// unfinished field is the last token
+		if end == -1 {
+			tokens = append(tokens, tokenErrNoVerb)
+			break
+		}
+
+		field := format[pos+1 : pos+1+end]
+
+		pos += end + 2
+
+		// resolve arg of the field
+		idEnd := strings.IndexAny(field, ",:")
+
+		if idEnd == -1 {
+			idEnd = len(field)
+		}
+
+		var (
+			arg int
+			tok token
+		)
+
+		switch id := field[:idEnd]; {
+		case id == "":
+			arg = nextArg
+			nextArg++
+		case ('0' <= id[0]) && (id[0] <= '9'):
+			var j uint
+
+			if arg, j = pickNumValue(id, 0); (arg == absentValue) || (j != uint(len(id))) {
+				arg = badArgNum
+			}
+		default:
+			var idx int
+			idx, names = nameIndex(names, id)
+			arg = int(namedArgBase) + idx
+		}
+
+		tok, ok := parseBraceSpec(field[idEnd:])
+
+		switch {
+		case !ok:
+			tok = tokenErrBadSpec
+			minSize += len(tok.value)
+		case arg == badArgNum:
+			tok = token{verb: verbNone, value: percentBangString + tok.value + badIndexString}
+			minSize += len(tok.value)
+		default:
+			tok.arg = uint32(arg)
+
+			if (arg < int(namedArgBase)) && (arg >= needArgs) {
+				needArgs = arg + 1
+			}
+		}
+
+		tokens = append(tokens, tok)
+		lit.open = false
+	}
+
+	return xfmt{
+		tokens:  tokens,
+		args:    uint(needArgs),
+		minSize: minSize,
+		names:   names,
+	}
+}
+
+// parseBraceSpec parses ["," alignment] [":" spec] part of the field into token without arg
+//go:nosplit
+func parseBraceSpec(spec string) (tok token, ok bool) {
+
+	tok = token{
+		verb:  verbValue,
+		value: verbValueString,
+		width: absentValue,
+		prec:  absentValue,
+	}
+
+	flags, aligned := flagNone, false
+
+	// .NET alignment
+	if (spec != "") && (spec[0] == charAlignment) {
+
+		i := uint(1)
+
+		if (i < uint(len(spec))) && (spec[i] == charSignMinus) {
+			flags |= flagPadRight
+			i++
+		}
+
+		width, j := pickNumValue(spec, i)
+
+		if (width == absentValue) || ((j < uint(len(spec))) && (spec[j] != charSpec)) {
+			return tok, false
+		}
+
+		tok.width, aligned, spec = int32(width), true, spec[j:]
+	}
+
+	if spec == "" {
+		return tok.withAlignment(flags, aligned), true
+	}
+
+	// here spec[0] is ':'
+	spec = spec[1:]
+
+	i := uint(0)
+
+	// [[fill]align]
+	if r, size := utf8.DecodeRuneInString(spec); (size < len(spec)) && isAlignChar(spec[size]) {
+
+		// fill char is stored in flags, so it must be ascii
+		if r >= utf8.RuneSelf {
+			return tok, false
+		}
+
+		flags = flags.withFill(byte(r))
+		i = uint(size)
+	}
+
+	if (i < uint(len(spec))) && isAlignChar(spec[i]) {
+
+		flags &^= flagPadRight
+
+		switch spec[i] {
+		case charAlignLeft:
+			flags |= flagPadRight
+		case charAlignCenter:
+			flags |= flagCenter
+		}
+
+		aligned = true
+		i++
+	}
+
+	// [sign]["#"]["0"]
+	if i < uint(len(spec)) {
+		switch spec[i] {
+		case flagCharPlus:
+			flags |= flagPlus
+			i++
+		case flagCharSpace:
+			flags |= flagSpace
+			i++
+		case charSignMinus:
+			i++
+		}
+	}
+
+	if (i < uint(len(spec))) && (spec[i] == flagCharSharp) {
+		flags |= flagSharp
+		i++
+	}
+
+	if (i < uint(len(spec))) && (spec[i] == flagCharZero) {
+
+		switch {
+		// explicitly aligned value is padded with zeros like in python (if fill char is absent)
+		case aligned && ((flags & fillMask) == 0):
+			flags = flags.withFill(charZero)
+		// otherwise zero padding is on the left like in printf
+		case !aligned:
+			flags, aligned = (flags|flagZero)&^flagPadRight, true
+		}
+
+		i++
+	}
+
+	// [width]["." precision], the width overrides .NET alignment
+	width, i := pickNumValue(spec, i)
+
+	if width != absentValue {
+		tok.width = int32(width)
+	}
+
+	if (i < uint(len(spec))) && (spec[i] == charDot) {
+
+		prec, j := pickNumValue(spec, i+1)
+
+		// "." without digits means 0 like in printf
+		if prec == absentValue {
+			prec = 0
+		}
+
+		tok.prec, i = int32(prec), j
+	}
+
+	// [type]
+	if i < uint(len(spec)) {
+
+		c, size := utf8.DecodeRuneInString(spec[i:])
+
+		// the type is the last char of spec
+		if i+uint(size) != uint(len(spec)) {
+			return tok, false
+		}
+
+		verb, isUpper := char2verb(c)
+
+		if isUpper {
+			flags |= flagUpperVerb
+		}
+
+		tok.verb, tok.value = verb, spec[i:]
+	}
+
+	return tok.withAlignment(flags, aligned), true
+}
+
+// withAlignment returns token with flags, values are aligned to the left if explicit alignment is absent
+// inlined
+//go:nosplit
+func (token token) withAlignment(flags flags, aligned bool) token {
+
+	if !aligned {
+		flags |= flagPadRight
+	}
+
+	token.flags = normalizeFlags(flags)
+
+	return token
+}
+
+// inlined
+//go:nosplit
+func isAlignChar(c byte) bool {
+	return (c == charAlignLeft) || (c == charAlignRight) || (c == charAlignCenter)
+}
+
+// Format formats according to brace format ("{}", "{0}", "{:>10}")
+func Format(format string, args ...string) string {
+	xfmt := braceFrontend.forge(format, false)
+	return xfmt.Sprint(args)
+}
+
+// Fformat formats according to brace format and writes to w
+func Fformat(w io.Writer, format string, args ...string) (n int, err error) {
+	xfmt := braceFrontend.forge(format, false)
+	return xfmt.Fprint(w, args)
+}
+
+// FormatMap is Format with named fields ("{name}", "{name:>10}") taking values from args, SEE SprintfMap
+func FormatMap(format string, args map[string]string) string {
+	xfmt := braceFrontend.forge(format, false)
+	return xfmt.sprintNamed(mapArgs(args))
+}
+
+// FormatFunc is Format with named fields taking values from lookup, SEE SprintfFunc
+func FormatFunc(format string, lookup func(name string) (string, bool)) string {
+	xfmt := braceFrontend.forge(format, false)
+	return xfmt.sprintNamed(funcArgs(lookup))
+}
@@ -0,0 +1,126 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"bytes"
+	"testing"
+)
+
+// go test -count=1 -v -run "^TestBraceFormat$"
+func TestBraceFormat(t *testing.T) {
+
+	defer SetCacheThreshold(CacheThreshold())
+
+	cases := [...]struct {
+		format string
+		args   []string
+		want   string
+	}{
+		{"", nil, ""},
+		{"no fields", nil, "no fields"},
+		{"user {} logged in from {}", []string{"bob", "host"}, "user bob logged in from host"},
+		{"{1} {0} {}", []string{"a", "b"}, "b a a"},
+		{"{{}} {{{}}} }", []string{"a"}, "{} {a} }"},
+		{"[{:10}][{:>10}][{:<6}][{:^7}]", []string{"ab", "ab", "ab", "ab"}, "[ab        ][        ab][ab    ][  ab   ]"},
+		{"[{:*^8}][{:->5}][{:05}][{:<05}]", []string{"ab", "ab", "ab", "ab"}, "[***ab***][---ab][000ab][ab000]"},
+		{"[{:^6}][{:.2}][{:>6.3}]", []string{"Привет", "Привет", "Привет"}, "[Привет][Пр][   При]"},
+		{"[{0,6}][{0,-6}][{0,6:q}]", []string{"ab"}, `[    ab][ab    ][  "ab"]`},
+		{"{:x}|{:X}|{:#x}|{:^ 9x}|{:q}|{:s}", []string{"ab", "ab", "ab", "ab", "ab", "ab"},
+			`6162|6162|0x6162|  61 62  |"ab"|ab`},
+		{"{:d}", []string{"ab"}, "%!d(string=ab)"},
+		{"{2}", []string{"a"}, "%!v(MISSING)"},
+		{"{0x}", nil, "%!v(BADINDEX)"},
+		{"{:zz}|{:é<5}|{0,x}", nil, "%!(BADSPEC)|%!(BADSPEC)|%!(BADSPEC)"},
+		{"tail {", nil, "tail %!(NOVERB)"},
+		{"{}", []string{"a", "b"}, "a%!(EXTRA string=b)"},
+		{"{name}", nil, "%!v(MISSING)"},
+	}
+
+	for _, threshold := range [...]uint{CacheAlways, CacheRepetitions, CacheDisabled} {
+
+		purgeCaches()
+
+		SetCacheThreshold(threshold)
+
+		for i := 0; i < 3; i++ {
+			for _, c := range cases {
+
+				if got := Format(c.format, c.args...); got != c.want {
+					t.Errorf("%d: %q: mismatch result: want <%s>, got <%s>", threshold, c.format, c.want, got)
+				}
+
+				var buf bytes.Buffer
+
+				if _, err := Fformat(&buf, c.format, c.args...); (err != nil) || (buf.String() != c.want) {
+					t.Errorf("%d: %q: Fformat mismatch result: want <%s>, got <%s> (err: %v)", threshold, c.format,
+						c.want, buf.String(), err)
+				}
+			}
+		}
+	}
+}
+
+// go test -count=1 -v -run "^TestBraceFormatSeparateCacheKeys$"
+func TestBraceFormatSeparateCacheKeys(t *testing.T) {
+
+	defer SetCacheThreshold(CacheThreshold())
+
+	purgeCaches()
+
+	SetCacheThreshold(CacheAlways)
+
+	// the same format string means different things for different frontends
+	const format = "{} %s"
+
+	if got, want := Sprintf(format, "a"), "{} a"; got != want {
+		t.Errorf("Sprintf mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	if got, want := Format(format, "a"), "a %s"; got != want {
+		t.Errorf("Format mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	// both frontends share the same cache under different keys
+	if got, want := Sprintf(format, "a"), "{} a"; got != want {
+		t.Errorf("cached Sprintf mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	if l := xfmtCache.Len(); l != 2 {
+		t.Errorf("cache len mismatch: want 2, got %d", l)
+	}
+}
+
+// go test -count=1 -v -run "^TestFormatMap$"
+func TestFormatMap(t *testing.T) {
+
+	args := map[string]string{"name": "bob"}
+
+	if got, want := FormatMap("[{name:>6}][{name}][{x}][{}]", args), "[   bob][bob][%!v(MISSING x)][%!v(MISSING)]"; got != want {
+		t.Errorf("FormatMap mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	lookup := func(name string) (string, bool) {
+		return name, name != ""
+	}
+
+	if got, want := FormatFunc("{id:^6}|{id:.1}", lookup), "  id  |i"; got != want {
+		t.Errorf("FormatFunc mismatch result: want <%s>, got <%s>", want, got)
+	}
+}
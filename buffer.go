@@ -305,6 +305,13 @@ func (b *buffer) WriteString(s string) {
 	}
 }
 
+// Clear drops buffered data, but keeps underlying storage for further writes
+// inlined
+//go:nosplit
+func (b *buffer) Clear() {
+	b.buf = b.buf[:0]
+}
+
 // NOTE implicit copy
 // inlined
 //go:nosplit
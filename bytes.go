@@ -0,0 +1,94 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"errors"
+	"io"
+	"os"
+)
+
+// INFO: byte slice args
+//      `fmt` treats strings and byte slices identically for `%s`, `%q` and `%x`, so []byte args are formatted as
+//      strings sharing their backing storage without copying. Slice headers of up to bytesArgsStackSize args are
+//      converted to string headers inside on-stack scratch array, so no memallocs are required. Use SprintfA with
+//      Str and Bytes args to mix strings and byte slices in a single call
+
+// max amount of args converted without memallocs
+const bytesArgsStackSize = 16
+
+// bytesArgs appends args as strings sharing their backing storage to `scratch`
+// WARN args must not be modified while result is in use
+//go:nosplit
+func bytesArgs(scratch []string, args [][]byte) []string {
+
+	for i := 0; i < len(args); i++ {
+		scratch = append(scratch, bytesString(args[i]))
+	}
+
+	return scratch
+}
+
+func FprintB(w io.Writer, args ...[]byte) (n int, err error) {
+	var scratch [bytesArgsStackSize]string
+	return fprint(w, false, bytesArgs(scratch[:0], args)...)
+}
+
+func PrintB(args ...[]byte) (n int, err error) {
+	return FprintB(os.Stdout, args...)
+}
+
+func SprintB(args ...[]byte) string {
+	var scratch [bytesArgsStackSize]string
+	return sprint(false, bytesArgs(scratch[:0], args)...)
+}
+
+func FprintlnB(w io.Writer, args ...[]byte) (n int, err error) {
+	var scratch [bytesArgsStackSize]string
+	return fprint(w, true, bytesArgs(scratch[:0], args)...)
+}
+
+func PrintlnB(args ...[]byte) (n int, err error) {
+	return FprintlnB(os.Stdout, args...)
+}
+
+func SprintlnB(args ...[]byte) string {
+	var scratch [bytesArgsStackSize]string
+	return sprint(true, bytesArgs(scratch[:0], args)...)
+}
+
+func FprintfB(w io.Writer, format string, args ...[]byte) (n int, err error) {
+	var scratch [bytesArgsStackSize]string
+	xfmt := forgeXfmt(format)
+	return xfmt.Fprint(w, bytesArgs(scratch[:0], args))
+}
+
+func PrintfB(format string, args ...[]byte) (n int, err error) {
+	return FprintfB(os.Stdout, format, args...)
+}
+
+func SprintfB(format string, args ...[]byte) string {
+	var scratch [bytesArgsStackSize]string
+	xfmt := forgeXfmt(format)
+	return xfmt.Sprint(bytesArgs(scratch[:0], args))
+}
+
+func ErrorfB(format string, args ...[]byte) error {
+	return errors.New(SprintfB(format, args...))
+}
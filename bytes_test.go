@@ -0,0 +1,71 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// go test -count=1 -v -run "^TestSprintfB$"
+func TestSprintfB(t *testing.T) {
+
+	args := [][]byte{[]byte("payload"), []byte("Привет\x00\xff"), nil, {}}
+
+	formats := [...]string{"%s", "%q", "%x", "% X", "%#x", "%10.3s|", "%-12q|", "%+q", "%#q"}
+
+	for _, format := range formats {
+		for _, arg := range args {
+			if want, got := fmt.Sprintf(format, arg), SprintfB(format, arg); got != want {
+				t.Errorf("%q %q: mismatch result: want <%s>, got <%s>", format, arg, want, got)
+			}
+		}
+	}
+
+	// more args than on-stack scratch can hold
+	many := make([][]byte, 2*bytesArgsStackSize)
+	strs := make([]string, len(many))
+
+	for i := range many {
+		many[i] = []byte{byte('a' + i)}
+		strs[i] = string(many[i])
+	}
+
+	// byte slices are printed as strings (unlike `fmt`, which prints them as lists of numbers without verbs)
+	if want, got := Sprintln(strs...), SprintlnB(many...); got != want {
+		t.Errorf("SprintlnB mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	if want, got := "ab", SprintB([]byte("a"), []byte("b")); got != want {
+		t.Errorf("SprintB mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	var buf bytes.Buffer
+
+	if _, err := FprintfB(&buf, "%s=%x", []byte("k"), []byte{0xbe, 0xef}); (err != nil) || (buf.String() != "k=beef") {
+		t.Errorf("FprintfB mismatch result: got <%s> (err: %v)", buf.String(), err)
+	}
+
+	k, v := []byte("key"), []byte("value")
+
+	assertMallocs(t, "FprintfB", 0, func() {
+		_, _ = FprintfB(mallocWriter{}, "%s=%q (%x)", k, v, v)
+	})
+}
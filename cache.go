@@ -56,14 +56,34 @@ func CacheThreshold() uint {
 //      of their data is in the non-heap constant section (bss)), the string keys of the following hashes will not
 //      actually use heap-allocated strings as values, so these hashes are cheap in terms of memory usage
 
+// syntax is the frontend of format (SEE printf.go), the same format string means different things for different
+// frontends, so cache keys are tagged by syntax
+type syntax uint8
+
+const (
+	syntaxPrintf syntax = iota // zero value, so printf-like format is the default one
+	syntaxBrace
+	syntaxC
+	syntaxEnv
+	syntaxMessage
+)
+
+// formatKey is the key of both counters and formats caches
+// NOTE struct key instead of tagged string one (syntax char + format) doesn't need memalloc for concatenation
+type formatKey struct {
+	format string
+	syntax syntax
+}
+
 type thresholdCounters struct {
 	lock     sync.Mutex
-	counters map[string]uint // lazy
+	counters map[formatKey]uint // lazy
 }
 
 // thread-safe
+// NOTE `transient` key shares memory that may be modified after the call, so it's copied if it should be stored
 //go:nosplit
-func (tc *thresholdCounters) Count(key string) (count uint) {
+func (tc *thresholdCounters) Count(key formatKey, transient bool) (count uint) {
 
 	tc.lock.Lock()
 	// hate defer, but we should unlock in case of any write (== memalloc) error
@@ -73,7 +93,12 @@ func (tc *thresholdCounters) Count(key string) (count uint) {
 		count = tc.counters[key] // got 0 if not found
 	} else {
 		// lazy map init, so no key in counters ==> count = 0
-		tc.counters = make(map[string]uint, 1)
+		tc.counters = make(map[formatKey]uint, 1)
+	}
+
+	// new transient key must be copied before storing
+	if (count == 0) && transient {
+		key.format = string(stringBytes(key.format))
 	}
 
 	// should inc usage counter ...
@@ -87,7 +112,7 @@ func (tc *thresholdCounters) Count(key string) (count uint) {
 
 // thread-safe
 //go:nosplit
-func (tc *thresholdCounters) Delete(key string) {
+func (tc *thresholdCounters) Delete(key formatKey) {
 	tc.lock.Lock()
 	delete(tc.counters, key)
 	tc.lock.Unlock()
@@ -123,38 +148,54 @@ var countersCache thresholdCounters
 //      it in place of the old one using `atomic. StorePointer`
 
 // ash map type alias
-type formatCacheMap = map[string]xfmt // NOTE xfmt by value
+type formatCacheMap = map[formatKey]xfmt // NOTE xfmt by value
 
+// NOTE version is incremented by every Reset, so formats parsed before the reset (e.g. with old included formats,
+//      SEE include.go) are never stored after it: the version is loaded before parsing and checked by Set
 type formatCache struct {
-	lock  sync.Mutex
-	cache unsafe.Pointer // formatCacheMap
+	lock    sync.Mutex
+	cache   unsafe.Pointer // formatCacheMap
+	version uintptr        // use uintptr for atomic store/load
 }
 
 // inlined
 // thread-safe
 //go:nosplit
-func (c *formatCache) Get(format string) (fmt xfmt, has bool) {
+func (c *formatCache) Get(key formatKey) (fmt xfmt, has bool) {
 
 	cache := atomic.LoadPointer(&c.cache)
 
 	// here `has` is default zero bool value `false`
 
 	if cache != nil {
-		fmt, has = (*((*formatCacheMap)((unsafe.Pointer)(&cache))))[format]
+		fmt, has = (*((*formatCacheMap)((unsafe.Pointer)(&cache))))[key]
 	}
 
 	return fmt, has
 }
 
+// thread-safe
+// inlined
+//go:nosplit
+func (c *formatCache) Version() uintptr {
+	return atomic.LoadUintptr(&c.version)
+}
+
+// Set stores fmt parsed since version of the cache, fmt is dropped if the cache was reset after that
 // thread-safe
 //-go:nosplit
-func (c *formatCache) Set(format string, fmt xfmt) {
+func (c *formatCache) Set(key formatKey, fmt xfmt, version uintptr) {
 
 	c.lock.Lock()
 
 	// hate defer, but we should unlock in case of any write (== memalloc) error
 	defer c.lock.Unlock()
 
+	// fmt may be outdated
+	if version != c.version {
+		return
+	}
+
 	ptr := atomic.LoadPointer(&c.cache)
 
 	oldCache := *((*formatCacheMap)((unsafe.Pointer)(&ptr)))
@@ -164,7 +205,7 @@ func (c *formatCache) Set(format string, fmt xfmt) {
 	if oldCache != nil {
 
 		// last check for key's existence
-		if _, has := oldCache[format]; has {
+		if _, has := oldCache[key]; has {
 			// format value already is in cache
 			return
 		}
@@ -180,11 +221,19 @@ func (c *formatCache) Set(format string, fmt xfmt) {
 		}
 	}
 
-	newCache[format] = fmt
+	newCache[key] = fmt
 
 	atomic.StorePointer(&c.cache, *(*unsafe.Pointer)(unsafe.Pointer(&newCache)))
 }
 
+// thread-safe
+func (c *formatCache) Reset() {
+	c.lock.Lock()
+	atomic.StorePointer(&c.cache, nil)
+	atomic.StoreUintptr(&c.version, c.version+1)
+	c.lock.Unlock()
+}
+
 // thread-safe
 // inlined
 //go:nosplit
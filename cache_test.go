@@ -58,18 +58,18 @@ func TestCacheConcurrentStability(t *testing.T) {
 		t := token{
 			verb:  verbNone,
 			value: source,
-			arg:   uint(i),
+			arg:   uint32(i),
 		}
 
 		v := xfmt{
-			[]token{t},
-			uint(i),
-			0,
+			tokens:  []token{t},
+			args:    uint(i),
+			minSize: 0,
 		}
 
 		<-startCh
 
-		cache.Set(source, v)
+		cache.Set(formatKey{format: source}, v, cache.Version())
 
 		g.Done()
 	}
@@ -89,7 +89,7 @@ func TestCacheConcurrentStability(t *testing.T) {
 	}
 
 	for i, s := range sources {
-		v, has := cache.Get(s)
+		v, has := cache.Get(formatKey{format: s})
 
 		if !has {
 			t.Fatalf("%d (%s): absent cache entry", i, s)
@@ -113,7 +113,7 @@ func TestCacheConcurrentStability(t *testing.T) {
 			t.Fatalf("%d (%s): mismatch token value: got %s", i, s, tt.value)
 		}
 
-		if tt.arg != uint(i) {
+		if tt.arg != uint32(i) {
 			t.Fatalf("%d (%s): mismatch token arg value: got %d", i, s, tt.arg)
 		}
 	}
@@ -0,0 +1,504 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// INFO: message catalog
+//      Catalog maps message IDs to translated formats per language. Formats are parsed and compiled once when they are
+//      added (so formatting of messages takes the fast path of cached formats), and catalog data is replaced
+//      atomically as a whole (copy-on-write like formatCache), so lookups are lock-free. Messages are searched in the
+//      language, then in its fallbacks (SetFallbacks) or, if there are no explicit ones, in its base language
+//      ("pt" for "pt-BR" and "pt_BR"), and then in the default fallbacks (SetFallbacks("", ...)); missing message
+//      ID is used as format itself like gettext does. Included formats (SEE include.go) are resolved when messages are
+//      added, so later registrations don't change them until the messages are added again
+//      Compiled translations are owned by the catalog and bypass the shared format cache: the same msgid has different
+//      formats in different catalogs and languages, so a cache key would have to be built of catalog, lang and msgid
+//      on every lookup, and the cache may drop them at any time (ResetCache, threshold), while they are parsed only
+//      once on loading. So ResetCache and SetCacheThreshold don't affect catalogs, only formats of missing messages
+//      (msgid itself) go through the cache
+
+// catalogContextSep separates gettext message context and message ID (SEE gettext `msgctxt`)
+const catalogContextSep = "\x04"
+
+// catalogData is immutable snapshot of catalog
+type catalogData struct {
+	messages  map[string]map[string]xfmt // lang -> msgid -> format
+	fallbacks map[string][]string        // lang -> fallback langs
+}
+
+// Catalog is a thread-safe set of translated formats of messages per language with fallbacks between languages,
+// its zero value is an empty catalog ready to use
+type Catalog struct {
+	lock sync.Mutex     // serializes writers
+	data unsafe.Pointer // *catalogData
+}
+
+// NewCatalog returns empty catalog
+func NewCatalog() *Catalog {
+	return new(Catalog)
+}
+
+// inlined
+//go:nosplit
+func (c *Catalog) load() *catalogData {
+	return (*catalogData)(atomic.LoadPointer(&c.data))
+}
+
+// update applies fn to the copy of the catalog data and replaces the data
+func (c *Catalog) update(fn func(data *catalogData)) {
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	data := &catalogData{
+		messages:  make(map[string]map[string]xfmt),
+		fallbacks: make(map[string][]string),
+	}
+
+	if old := c.load(); old != nil {
+
+		// NOTE per lang maps are copied only by the writers, which modify them
+		for lang, messages := range old.messages {
+			data.messages[lang] = messages
+		}
+
+		for lang, fallbacks := range old.fallbacks {
+			data.fallbacks[lang] = fallbacks
+		}
+	}
+
+	fn(data)
+
+	atomic.StorePointer(&c.data, unsafe.Pointer(data))
+}
+
+// compileFormat parses and compiles format of the catalog, the same way as cached formats
+//go:nosplit
+func compileFormat(format string) xfmt {
+
+	xfmt := parseFormat(format)
+
+	xfmt.shrink()
+	xfmt.chain = xfmt.compile()
+
+	return xfmt
+}
+
+// AddMessages adds formats of messages (msgid -> format) of lang replacing existing ones
+func (c *Catalog) AddMessages(lang string, messages map[string]string) {
+
+	// parse outside of the lock
+	compiled := make(map[string]xfmt, len(messages))
+
+	for msgid, format := range messages {
+		compiled[msgid] = compileFormat(format)
+	}
+
+	c.update(func(data *catalogData) {
+
+		old := data.messages[lang]
+
+		merged := make(map[string]xfmt, len(old)+len(compiled))
+
+		for msgid, xfmt := range old {
+			merged[msgid] = xfmt
+		}
+
+		for msgid, xfmt := range compiled {
+			merged[msgid] = xfmt
+		}
+
+		data.messages[lang] = merged
+	})
+}
+
+// SetFallbacks sets fallback languages of lang, empty lang sets default fallbacks used for all languages
+func (c *Catalog) SetFallbacks(lang string, fallbacks ...string) {
+
+	fallbacks = append([]string(nil), fallbacks...)
+
+	c.update(func(data *catalogData) {
+		data.fallbacks[lang] = fallbacks
+	})
+}
+
+// baseLang returns base language of the language tag ("pt" for "pt-BR" and "pt_BR") or "" if there is no one
+// inlined
+//go:nosplit
+func baseLang(lang string) string {
+
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		return lang[:i]
+	}
+
+	return ""
+}
+
+// lookupIn looks up msgid in lang only
+//go:nosplit
+func (data *catalogData) lookupIn(lang, msgid string) (xfmt xfmt, ok bool) {
+	xfmt, ok = data.messages[lang][msgid]
+	return xfmt, ok
+}
+
+// lookup looks up msgid through the fallback chain of lang
+func (data *catalogData) lookup(lang, msgid string) (xfmt xfmt, ok bool) {
+
+	if data == nil {
+		return xfmt, false
+	}
+
+	if xfmt, ok = data.lookupIn(lang, msgid); ok {
+		return xfmt, true
+	}
+
+	if fallbacks, has := data.fallbacks[lang]; has {
+		for _, fallback := range fallbacks {
+			if xfmt, ok = data.lookupIn(fallback, msgid); ok {
+				return xfmt, true
+			}
+		}
+	} else if base := baseLang(lang); base != "" {
+		if xfmt, ok = data.lookupIn(base, msgid); ok {
+			return xfmt, true
+		}
+	}
+
+	for _, fallback := range data.fallbacks[""] {
+		if xfmt, ok = data.lookupIn(fallback, msgid); ok {
+			return xfmt, true
+		}
+	}
+
+	return xfmt, false
+}
+
+// Has reports whether msgid is translated for lang (including fallbacks)
+func (c *Catalog) Has(lang, msgid string) bool {
+	_, ok := c.load().lookup(lang, msgid)
+	return ok
+}
+
+// forge returns format of msgid for lang, msgid is used as format if it's missing; missing message with context
+// ("context\x04msgid") falls back to the message without context, so the context never leaks into the output
+//go:nosplit
+func (c *Catalog) forge(lang, msgid string) xfmt {
+
+	data := c.load()
+
+	if xfmt, ok := data.lookup(lang, msgid); ok {
+		return xfmt
+	}
+
+	if i := strings.Index(msgid, catalogContextSep); i != -1 {
+
+		msgid = msgid[i+len(catalogContextSep):]
+
+		if xfmt, ok := data.lookup(lang, msgid); ok {
+			return xfmt
+		}
+	}
+
+	return forgeXfmt(msgid)
+}
+
+// contextKey returns catalog key of msgid in context ctxt (SEE gettext `pgettext`)
+// inlined
+//go:nosplit
+func contextKey(ctxt, msgid string) string {
+
+	if ctxt == "" {
+		return msgid
+	}
+
+	return ctxt + catalogContextSep + msgid
+}
+
+// Sprintf formats translated format of msgid
+func (c *Catalog) Sprintf(lang, msgid string, args ...string) string {
+	xfmt := c.forge(lang, msgid)
+	return xfmt.Sprint(args)
+}
+
+// Fprintf formats translated format of msgid and writes to w
+func (c *Catalog) Fprintf(w io.Writer, lang, msgid string, args ...string) (n int, err error) {
+	xfmt := c.forge(lang, msgid)
+	return xfmt.Fprint(w, args)
+}
+
+// Errorf formats translated format of msgid as error
+func (c *Catalog) Errorf(lang, msgid string, args ...string) error {
+	xfmt := c.forge(lang, msgid)
+	return errors.New(xfmt.Sprint(args))
+}
+
+// SprintfA formats translated format of msgid with typed args
+func (c *Catalog) SprintfA(lang, msgid string, args ...Arg) string {
+	xfmt := c.forge(lang, msgid)
+	return xfmt.SprintA(args)
+}
+
+// SprintfCtx formats translated format of msgid in context ctxt (gettext `msgctxt`), message missing in the context
+// falls back to msgid without context
+func (c *Catalog) SprintfCtx(lang, ctxt, msgid string, args ...string) string {
+	xfmt := c.forge(lang, contextKey(ctxt, msgid))
+	return xfmt.Sprint(args)
+}
+
+// SprintfACtx is SprintfCtx with typed args
+func (c *Catalog) SprintfACtx(lang, ctxt, msgid string, args ...Arg) string {
+	xfmt := c.forge(lang, contextKey(ctxt, msgid))
+	return xfmt.SprintA(args)
+}
+
+// HasCtx reports whether msgid in context ctxt is translated for lang (including fallbacks)
+func (c *Catalog) HasCtx(lang, ctxt, msgid string) bool {
+	return c.Has(lang, contextKey(ctxt, msgid))
+}
+
+// CatalogError is the error of catalog loading
+type CatalogError struct {
+	Line   int // line of PO file, 0 for JSON catalog
+	Reason string
+}
+
+func (e *CatalogError) Error() string {
+
+	if e.Line == 0 {
+		return "xfmt: bad catalog: " + e.Reason
+	}
+
+	return "xfmt: bad PO file at line " + strconv.Itoa(e.Line) + ": " + e.Reason
+}
+
+// reasons of CatalogError
+const (
+	poReasonKeyword  = "unknown keyword"
+	poReasonString   = "bad string"
+	poReasonOrphan   = "string without keyword"
+	poReasonNoMsgid  = "msgstr without msgid"
+	poReasonNoMsgstr = "msgid without msgstr"
+	// translations mismatched their msgids are reported by checkTranslation, SEE signature.go
+)
+
+// PO keywords
+const (
+	poMsgctxt     = "msgctxt"
+	poMsgid       = "msgid"
+	poMsgidPlural = "msgid_plural"
+	poMsgstr      = "msgstr"
+	poMsgstrFirst = "msgstr[0]" // only the first plural form is used
+	poFuzzy       = "fuzzy"
+)
+
+// poEntry is the entry of PO file being parsed
+type poEntry struct {
+	ctxt, id, str string
+	line          int // line of msgid
+	fuzzy         bool
+	hasID, hasStr bool
+	field         *string // field of the last keyword, continuation strings are appended to it
+}
+
+// add adds complete entry to messages, header (empty msgid), fuzzy and untranslated entries are skipped;
+// returns error if translation doesn't match msgid
+func (e *poEntry) add(messages map[string]string) error {
+
+	if e.hasID && e.hasStr && (e.id != "") && (e.str != "") && !e.fuzzy {
+
+		if reason := checkTranslation(e.id, e.str); reason != "" {
+			return &CatalogError{Line: e.line, Reason: reason}
+		}
+
+		key := e.id
+
+		if e.ctxt != "" {
+			key = e.ctxt + catalogContextSep + e.id
+		}
+
+		messages[key] = e.str
+	}
+
+	*e = poEntry{}
+
+	return nil
+}
+
+// LoadPO loads translations of lang from gettext PO file, translated strings must be xfmt formats; header, fuzzy
+// and untranslated entries are skipped, only the first plural form (msgstr[0]) is used, messages with context are
+// added as "context\x04msgid" like gettext does; nothing is loaded if any of translations doesn't match its msgid
+// (SEE CompareSignatures)
+func (c *Catalog) LoadPO(lang string, r io.Reader) error {
+
+	messages := make(map[string]string)
+
+	var e poEntry
+
+	sc := bufio.NewScanner(r)
+
+	line := 0
+
+	for sc.Scan() {
+
+		line++
+
+		s := strings.TrimSpace(sc.Text())
+
+		switch {
+		case s == "":
+			if e.hasID && !e.hasStr {
+				return &CatalogError{Line: line, Reason: poReasonNoMsgstr}
+			}
+
+			if err := e.add(messages); err != nil {
+				return err
+			}
+
+			continue
+		case strings.HasPrefix(s, "#,"):
+			// flags comment of the next entry
+			if e.hasStr {
+				if err := e.add(messages); err != nil {
+					return err
+				}
+			}
+
+			e.fuzzy = e.fuzzy || strings.Contains(s, poFuzzy)
+			continue
+		case s[0] == '#':
+			continue
+		case s[0] == charDoubleQuote:
+			if e.field == nil {
+				return &CatalogError{Line: line, Reason: poReasonOrphan}
+			}
+
+			str, err := strconv.Unquote(s)
+
+			if err != nil {
+				return &CatalogError{Line: line, Reason: poReasonString}
+			}
+
+			*e.field += str
+			continue
+		}
+
+		keyword, value := s, ""
+
+		if i := strings.IndexByte(s, charSpace); i > 0 {
+			keyword, value = s[:i], strings.TrimSpace(s[i+1:])
+		}
+
+		str, err := strconv.Unquote(value)
+
+		if err != nil {
+			return &CatalogError{Line: line, Reason: poReasonString}
+		}
+
+		switch {
+		case keyword == poMsgctxt:
+			if e.hasStr {
+				if err := e.add(messages); err != nil {
+					return err
+				}
+			}
+
+			e.ctxt, e.field = str, &e.ctxt
+		case keyword == poMsgid:
+			if e.hasID && !e.hasStr {
+				return &CatalogError{Line: line, Reason: poReasonNoMsgstr}
+			}
+
+			if e.hasStr {
+				if err := e.add(messages); err != nil {
+					return err
+				}
+			}
+
+			e.id, e.hasID, e.line, e.field = str, true, line, &e.id
+		case keyword == poMsgidPlural:
+			// plural id isn't used
+			var plural string
+			e.field = &plural
+		case (keyword == poMsgstr) || (keyword == poMsgstrFirst):
+			if !e.hasID {
+				return &CatalogError{Line: line, Reason: poReasonNoMsgid}
+			}
+
+			e.str, e.hasStr, e.field = str, true, &e.str
+		case strings.HasPrefix(keyword, poMsgstr+"["):
+			// other plural forms aren't used
+			var form string
+			e.field = &form
+		default:
+			return &CatalogError{Line: line, Reason: poReasonKeyword}
+		}
+	}
+
+	if err := sc.Err(); err != nil {
+		return err
+	}
+
+	if e.hasID && !e.hasStr {
+		return &CatalogError{Line: line, Reason: poReasonNoMsgstr}
+	}
+
+	if err := e.add(messages); err != nil {
+		return err
+	}
+
+	c.AddMessages(lang, messages)
+
+	return nil
+}
+
+// LoadJSON loads translations from JSON catalog `{"lang": {"msgid": "format", ...}, ...}`, nothing is loaded if any
+// of translations doesn't match its msgid
+func (c *Catalog) LoadJSON(r io.Reader) error {
+
+	var langs map[string]map[string]string
+
+	if err := json.NewDecoder(r).Decode(&langs); err != nil {
+		return &CatalogError{Reason: err.Error()}
+	}
+
+	for lang, messages := range langs {
+		for msgid, format := range messages {
+			if reason := checkTranslation(msgid, format); reason != "" {
+				return &CatalogError{Reason: lang + ": " + reason}
+			}
+		}
+	}
+
+	for lang, messages := range langs {
+		c.AddMessages(lang, messages)
+	}
+
+	return nil
+}
@@ -0,0 +1,223 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testPO = `# Russian translations
+msgid ""
+msgstr ""
+"Language: ru\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+
+#: main.go:10
+msgid "Hello, %s!"
+msgstr "Привет, %s!"
+
+msgid "user %[1]s logged in from %[2]s"
+msgstr ""
+"пользователь %[1]s вошел "
+"с адреса %[2]s"
+
+#, fuzzy
+msgid "fuzzy %s"
+msgstr "нечетко %s"
+
+msgid "untranslated"
+msgstr ""
+
+msgctxt "menu"
+msgid "Open"
+msgstr "Открыть"
+
+msgid "%d file"
+msgid_plural "%d files"
+msgstr[0] "%d файл"
+msgstr[1] "%d файла"
+msgstr[2] "%d файлов"
+
+#~ msgid "obsolete"
+#~ msgstr "устарело"
+`
+
+// go test -count=1 -v -run "^TestCatalogLoadPO$"
+func TestCatalogLoadPO(t *testing.T) {
+
+	c := NewCatalog()
+
+	if err := c.LoadPO("ru", strings.NewReader(testPO)); err != nil {
+		t.Fatalf("LoadPO error: %v", err)
+	}
+
+	cases := [...]struct {
+		msgid string
+		args  []string
+		want  string
+	}{
+		{"Hello, %s!", []string{"Мир"}, "Привет, Мир!"},
+		{"user %[1]s logged in from %[2]s", []string{"bob", "host"}, "пользователь bob вошел с адреса host"},
+		{"fuzzy %s", []string{"x"}, "fuzzy x"},
+		{"untranslated", nil, "untranslated"},
+		{"menu" + catalogContextSep + "Open", nil, "Открыть"},
+		{"Open", nil, "Open"},
+		{"menu" + catalogContextSep + "Close", nil, "Close"},
+		{"menu" + catalogContextSep + "Hello, %s!", []string{"Мир"}, "Привет, Мир!"},
+		{"obsolete", nil, "obsolete"},
+		{"", nil, ""},
+	}
+
+	for _, c2 := range cases {
+		if got := c.Sprintf("ru", c2.msgid, c2.args...); got != c2.want {
+			t.Errorf("%q: mismatch result: want <%s>, got <%s>", c2.msgid, c2.want, got)
+		}
+	}
+
+	if got, want := c.SprintfA("ru", "%d file", Int(1)), "1 файл"; got != want {
+		t.Errorf("plural: mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	// message context
+	ctxCases := [...]struct {
+		ctxt, msgid string
+		args        []string
+		want        string
+	}{
+		{"menu", "Open", nil, "Открыть"},
+		{"toolbar", "Open", nil, "Open"},
+		{"", "Open", nil, "Open"},
+		{"menu", "Hello, %s!", []string{"Мир"}, "Привет, Мир!"},
+		{"menu", "Save %s", []string{"a"}, "Save a"},
+	}
+
+	for _, c2 := range ctxCases {
+		if got := c.SprintfCtx("ru", c2.ctxt, c2.msgid, c2.args...); got != c2.want {
+			t.Errorf("%q %q: SprintfCtx mismatch result: want <%s>, got <%s>", c2.ctxt, c2.msgid, c2.want, got)
+		}
+	}
+
+	if got, want := c.SprintfACtx("ru", "menu", "%d file", Int(1)), "1 файл"; got != want {
+		t.Errorf("SprintfACtx mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	if !c.HasCtx("ru", "menu", "Open") || c.HasCtx("ru", "toolbar", "Open") || c.HasCtx("ru", "", "Open") {
+		t.Error("HasCtx mismatch result")
+	}
+
+	var buf bytes.Buffer
+
+	if _, err := c.Fprintf(&buf, "ru", "Hello, %s!", "Мир"); (err != nil) || (buf.String() != "Привет, Мир!") {
+		t.Errorf("Fprintf mismatch result: got <%s> (err: %v)", buf.String(), err)
+	}
+
+	if err := c.Errorf("ru", "Hello, %s!", "Мир"); err.Error() != "Привет, Мир!" {
+		t.Errorf("Errorf mismatch result: got <%v>", err)
+	}
+}
+
+// go test -count=1 -v -run "^TestCatalogLoadPOErrors$"
+func TestCatalogLoadPOErrors(t *testing.T) {
+
+	cases := [...]struct {
+		po     string
+		line   int
+		reason string
+	}{
+		{"msgid \"a\"\nmsgstr \"b\nc\"\n", 2, poReasonString},
+		{"\"orphan\"\n", 1, poReasonOrphan},
+		{"msgstr \"b\"\n", 1, poReasonNoMsgid},
+		{"msgid \"a\"\n\nmsgid \"b\"\nmsgstr \"c\"\n", 2, poReasonNoMsgstr},
+		{"msgid \"a\"\nmsgid \"b\"\nmsgstr \"c\"\n", 2, poReasonNoMsgstr},
+		{"msgid \"a\"\n", 1, poReasonNoMsgstr},
+		{"msgidx \"a\"\n", 1, poReasonKeyword},
+	}
+
+	for _, c := range cases {
+
+		err := NewCatalog().LoadPO("en", strings.NewReader(c.po))
+
+		var cerr *CatalogError
+
+		if !errors.As(err, &cerr) {
+			t.Errorf("%q: unexpected error: %v", c.po, err)
+			continue
+		}
+
+		if (cerr.Line != c.line) || (cerr.Reason != c.reason) {
+			t.Errorf("%q: mismatch error: want %d: %s, got %d: %s", c.po, c.line, c.reason, cerr.Line, cerr.Reason)
+		}
+	}
+}
+
+// go test -count=1 -v -run "^TestCatalogFallbacks$"
+func TestCatalogFallbacks(t *testing.T) {
+
+	c := NewCatalog()
+
+	const catalog = `{
+		"en": {"hello": "Hello, %s!", "bye": "Bye, %s!", "only-en": "English"},
+		"pt": {"hello": "Olá, %s!", "bye": "Tchau, %s!"},
+		"pt-BR": {"bye": "Falou, %s!"},
+		"uk": {"hello": "Привіт, %s!"},
+		"ru": {"hello": "Привет, %s!", "bye": "Пока, %s!"}
+	}`
+
+	if err := c.LoadJSON(strings.NewReader(catalog)); err != nil {
+		t.Fatalf("LoadJSON error: %v", err)
+	}
+
+	c.SetFallbacks("uk", "ru")
+	c.SetFallbacks("", "en")
+
+	cases := [...]struct {
+		lang, msgid string
+		want        string
+	}{
+		{"pt-BR", "bye", "Falou, X!"},
+		{"pt-BR", "hello", "Olá, X!"},
+		{"pt_PT", "hello", "Olá, X!"},
+		{"uk", "hello", "Привіт, X!"},
+		{"uk", "bye", "Пока, X!"},
+		{"uk", "only-en", "English%!(EXTRA string=X)"},
+		{"de", "hello", "Hello, X!"},
+		{"de", "missing %s", "missing X"},
+	}
+
+	for _, c2 := range cases {
+		if got := c.Sprintf(c2.lang, c2.msgid, "X"); got != c2.want {
+			t.Errorf("%s %q: mismatch result: want <%s>, got <%s>", c2.lang, c2.msgid, c2.want, got)
+		}
+	}
+
+	if !c.Has("pt-BR", "hello") || c.Has("pt-BR", "missing %s") {
+		t.Errorf("Has mismatch result")
+	}
+
+	if err := c.LoadJSON(strings.NewReader(`{"en": ["x"]}`)); err == nil {
+		t.Errorf("LoadJSON: expected error")
+	}
+
+	assertMallocs(t, "Catalog.Fprintf", 0, func() {
+		_, _ = c.Fprintf(mallocWriter{}, "pt-BR", "hello", "X")
+	})
+}
@@ -0,0 +1,339 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"unicode/utf8"
+)
+
+// INFO: C printf formats
+//      C formats from legacy configs are converted into equivalent xfmt formats: POSIX positional args "%n$s" and
+//      "*m$" become "[n]" arg indexes, length modifiers (hh, h, l, ll, j, z, t, L, q) are dropped, because args
+//      have their own types, and C-only conversions are translated (`%i`, `%u` to `%d`, `%a` to `%x`, `%S` to
+//      `%s`, `%C` to `%c`). `%n`, `'` flag and mixing of numbered and unnumbered args are rejected. C formats are
+//      cached by the same format cache under their own keys, so every C format is converted only once
+
+const (
+	charArgNumEnd   = '$'
+	flagCharGroup   = '\''
+	verbCharPointer = 'p'
+	verbCharWrite   = 'n'
+)
+
+// CFormatError is the error of C format conversion
+type CFormatError struct {
+	Offset int    // offset of the erroneous directive inside format
+	Reason string // what is wrong
+}
+
+func (e *CFormatError) Error() string {
+	return "xfmt: bad C format directive at offset " + strconv.Itoa(e.Offset) + ": " + e.Reason
+}
+
+// reasons of CFormatError
+const (
+	cReasonNoConversion = "no conversion"
+	cReasonBadConv      = "unknown conversion"
+	cReasonWrite        = "%n is not supported"
+	cReasonGroup        = "' flag is not supported"
+	cReasonBadArgNum    = "bad arg number"
+	cReasonMixedArgs    = "mixed numbered and unnumbered args"
+)
+
+// cConversions maps C conversion chars to xfmt verb chars, zero for unknown conversions
+var cConversions = [utf8.RuneSelf]byte{
+	'd': verbCharDecimal, 'i': verbCharDecimal, 'u': verbCharDecimal,
+	'o': verbCharOctal, 'x': verbCharHex, 'X': 'X',
+	'e': verbCharExp, 'E': 'E', 'f': verbCharFloat, 'F': 'F', 'g': verbCharGeneral, 'G': 'G',
+	'a': verbCharHex, 'A': 'X',
+	'c': 'c', 'C': 'c', 's': verbCharString, 'S': verbCharString,
+	'p': verbCharPointer,
+}
+
+// max amount of bytes of converted directive kept on stack
+const cDirectiveStackSize = 32
+
+// kinds of args used by C directive
+const (
+	cArgsNone       = iota // "%%"
+	cArgsNumbered          // "%1$s"
+	cArgsUnnumbered        // "%s"
+
+	cArgsKinds
+)
+
+// ConvertCFormat rewrites C printf format into the equivalent xfmt (Go style) format, format is returned as is
+// (without memalloc) if it needs no rewriting
+func ConvertCFormat(format string) (string, error) {
+
+	var (
+		out     []byte // lazy, nil while result is equal to format
+		scratch [cDirectiveStackSize]byte
+	)
+
+	// kinds of args used by the format
+	var used [cArgsKinds]bool
+
+	// format[copied:] isn't processed yet
+	copied := 0
+
+	for i := 0; i < len(format); i++ {
+
+		if format[i] != charPercent {
+			continue
+		}
+
+		start := i
+
+		d, end, args, err := convertCDirective(scratch[:0], format, i)
+
+		if err != nil {
+			return "", err
+		}
+
+		if used[args] = true; used[cArgsNumbered] && used[cArgsUnnumbered] {
+			return "", &CFormatError{Offset: start, Reason: cReasonMixedArgs}
+		}
+
+		// rewritten directive
+		if string(d) != format[start:end] {
+
+			if out == nil {
+				out = make([]byte, 0, len(format)+len(d))
+			}
+
+			out = append(out, format[copied:start]...)
+			out = append(out, d...)
+
+			copied = end
+		}
+
+		i = end - 1
+	}
+
+	if out == nil {
+		return format, nil
+	}
+
+	return string(append(out, format[copied:]...)), nil
+}
+
+// convertCDirective appends xfmt version of C directive starting at format[i] ('%') to dst
+// SEE https://pubs.opengroup.org/onlinepubs/9699919799/functions/fprintf.html
+func convertCDirective(dst []byte, format string, i int) (d []byte, end, args int, err error) {
+
+	start := i
+
+	// skip '%'
+	i++
+
+	// "%n$" arg number
+	argNum, j, numbered, proper := cArgNum(format, i)
+
+	if !proper {
+		return nil, 0, 0, &CFormatError{Offset: start, Reason: cReasonBadArgNum}
+	}
+
+	i = j
+
+	dst = append(dst, charPercent)
+
+	// flags
+	for ; i < len(format); i++ {
+
+		c := format[i]
+
+		if c == flagCharGroup {
+			return nil, 0, 0, &CFormatError{Offset: start, Reason: cReasonGroup}
+		}
+
+		if (c >= utf8.RuneSelf) || (charFlags[c] == flagNone) {
+			break
+		}
+
+		dst = append(dst, c)
+	}
+
+	// width and precision
+	for k := 0; k < 2; k++ {
+
+		if k == 1 {
+
+			if (i >= len(format)) || (format[i] != charDot) {
+				break
+			}
+
+			dst = append(dst, charDot)
+			i++
+		}
+
+		if (i < len(format)) && (format[i] == charAsterisk) {
+
+			i++
+
+			// "*m$"
+			n, j, found, proper := cArgNum(format, i)
+
+			switch {
+			case !proper:
+				return nil, 0, 0, &CFormatError{Offset: start, Reason: cReasonBadArgNum}
+			case found != numbered:
+				return nil, 0, 0, &CFormatError{Offset: start, Reason: cReasonMixedArgs}
+			case found:
+				dst = appendArgNum(dst, n)
+				i = j
+			}
+
+			dst = append(dst, charAsterisk)
+
+			continue
+		}
+
+		for ; (i < len(format)) && ('0' <= format[i]) && (format[i] <= '9'); i++ {
+			dst = append(dst, format[i])
+		}
+	}
+
+	// length modifiers are dropped
+	for ; i < len(format); i++ {
+		if c := format[i]; (c != 'h') && (c != 'l') && (c != 'j') && (c != 'z') && (c != 't') && (c != 'L') &&
+			(c != 'q') {
+			break
+		}
+	}
+
+	if i >= len(format) {
+		return nil, 0, 0, &CFormatError{Offset: start, Reason: cReasonNoConversion}
+	}
+
+	c := format[i]
+
+	switch {
+	case c == charPercent:
+		// "%%" ignores everything (C requires nothing between percents)
+		return append(dst[:1], charPercent), i + 1, cArgsNone, nil
+	case c == verbCharWrite:
+		return nil, 0, 0, &CFormatError{Offset: start, Reason: cReasonWrite}
+	case (c >= utf8.RuneSelf) || (cConversions[c] == 0):
+		return nil, 0, 0, &CFormatError{Offset: start, Reason: cReasonBadConv}
+	}
+
+	args = cArgsUnnumbered
+
+	if numbered {
+		dst, args = appendArgNum(dst, argNum), cArgsNumbered
+	}
+
+	return append(dst, cConversions[c]), i + 1, args, nil
+}
+
+// cArgNum parses "n$" at format[i] and returns 0-based arg number
+// NOTE `proper` is always true when `found` is false, SEE tryArgNum()
+//go:nosplit
+func cArgNum(format string, i int) (n, j int, found, proper bool) {
+
+	k := uint(i)
+
+	for ; (k < uint(len(format))) && ('0' <= format[k]) && (format[k] <= '9'); k++ {
+	}
+
+	// digits without '$' are width
+	if (k == uint(i)) || (k >= uint(len(format))) || (format[k] != charArgNumEnd) {
+		return 0, i, false, true
+	}
+
+	// "0$" and too large numbers are bad arg nums
+	if num, _ := pickNumValue(format[:k], uint(i)); num > 0 {
+		return num - 1, int(k) + 1, true, true
+	}
+
+	return 0, int(k) + 1, true, false
+}
+
+// appendArgNum appends "[n]" of 0-based arg number n
+// inlined
+//go:nosplit
+func appendArgNum(dst []byte, n int) []byte {
+
+	dst = append(dst, charOpenArgNum)
+	dst = strconv.AppendInt(dst, int64(n+1), 10)
+
+	return append(dst, charCloseArgNum)
+}
+
+// C formats frontend
+
+var cFrontend = frontend{
+	syntax: syntaxC,
+	parse:  parseCFormat,
+}
+
+// parseCFormat parses C format converted to xfmt format, format that can't be converted is printed as error mark
+// NOTE retval by value
+func parseCFormat(format string) xfmt {
+
+	converted, err := ConvertCFormat(format)
+
+	if err != nil {
+
+		var cerr *CFormatError
+
+		reason := err.Error()
+
+		if errors.As(err, &cerr) {
+			reason = cerr.Reason
+		}
+
+		value := badCFormatString + reason + rightParensStr
+
+		return xfmt{
+			tokens:  []token{{verb: verbNone, value: value}},
+			minSize: len(value),
+		}
+	}
+
+	return parseFormat(converted)
+}
+
+// FprintfC is Fprintf for C printf format, SEE ConvertCFormat
+func FprintfC(w io.Writer, format string, args ...string) (n int, err error) {
+	xfmt := cFrontend.forge(format, false)
+	return xfmt.Fprint(w, args)
+}
+
+// SprintfC is Sprintf for C printf format, SEE ConvertCFormat
+func SprintfC(format string, args ...string) string {
+	xfmt := cFrontend.forge(format, false)
+	return xfmt.Sprint(args)
+}
+
+// FprintfCA is FprintfA for C printf format, SEE ConvertCFormat
+func FprintfCA(w io.Writer, format string, args ...Arg) (n int, err error) {
+	xfmt := cFrontend.forge(format, false)
+	return xfmt.FprintA(w, args)
+}
+
+// SprintfCA is SprintfA for C printf format, SEE ConvertCFormat
+func SprintfCA(format string, args ...Arg) string {
+	xfmt := cFrontend.forge(format, false)
+	return xfmt.SprintA(args)
+}
@@ -0,0 +1,142 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"errors"
+	"testing"
+)
+
+// go test -count=1 -v -run "^TestConvertCFormat$"
+func TestConvertCFormat(t *testing.T) {
+
+	cases := [...]struct {
+		format string
+		want   string
+	}{
+		{"", ""},
+		{"100%%", "100%%"},
+		{"plain %s and %-10s|", "plain %s and %-10s|"},
+		{"%.*s|%*.*s|%+05d", "%.*s|%*.*s|%+05d"},
+		{"%ls %zs %lld %hhu %Lf %jd %td %qd", "%s %s %d %d %f %d %d %d"},
+		{"%i|%u|%a|%A|%S|%C|%5%", "%d|%d|%x|%X|%s|%c|%%"},
+		{"%2$s %1$s", "%[2]s %[1]s"},
+		{"%2$-*3$s|%1$.*2$f|%1$#010x", "%-[3]*[2]s|%.[2]*[1]f|%#010[1]x"},
+	}
+
+	for _, c := range cases {
+
+		got, err := ConvertCFormat(c.format)
+
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", c.format, err)
+			continue
+		}
+
+		if got != c.want {
+			t.Errorf("%q: mismatch result: want <%s>, got <%s>", c.format, c.want, got)
+		}
+	}
+
+	// formats without C-only syntax are returned as is
+	assertMallocs(t, "ConvertCFormat", 0, func() {
+		_, _ = ConvertCFormat("user %-10s logged in from %s:%d (%.*s)")
+	})
+}
+
+// go test -count=1 -v -run "^TestConvertCFormatErrors$"
+func TestConvertCFormatErrors(t *testing.T) {
+
+	cases := [...]struct {
+		format string
+		offset int
+		reason string
+	}{
+		{"count%n", 5, cReasonWrite},
+		{"%'d", 0, cReasonGroup},
+		{"%1$s %s", 5, cReasonMixedArgs},
+		{"%s %1$s", 3, cReasonMixedArgs},
+		{"%1$*s", 0, cReasonMixedArgs},
+		{"%*1$s", 0, cReasonMixedArgs},
+		{"%0$s", 0, cReasonBadArgNum},
+		{"%99999999999$s", 0, cReasonBadArgNum},
+		{"%s %", 3, cReasonNoConversion},
+		{"%5l", 0, cReasonNoConversion},
+		{"%y", 0, cReasonBadConv},
+		{"%Привет", 0, cReasonBadConv},
+	}
+
+	for _, c := range cases {
+
+		_, err := ConvertCFormat(c.format)
+
+		var cerr *CFormatError
+
+		if !errors.As(err, &cerr) {
+			t.Errorf("%q: unexpected error: %v", c.format, err)
+			continue
+		}
+
+		if (cerr.Offset != c.offset) || (cerr.Reason != c.reason) {
+			t.Errorf("%q: mismatch error: want %d: %s, got %d: %s", c.format, c.offset, c.reason, cerr.Offset,
+				cerr.Reason)
+		}
+	}
+}
+
+// go test -count=1 -v -run "^TestSprintfC$"
+func TestSprintfC(t *testing.T) {
+
+	defer SetCacheThreshold(CacheThreshold())
+
+	for _, threshold := range [...]uint{CacheAlways, CacheRepetitions, CacheDisabled} {
+
+		purgeCaches()
+
+		SetCacheThreshold(threshold)
+
+		for i := 0; i < 3; i++ {
+
+			// format that can't be converted is printed as error mark
+			if got, want := SprintfC("%2$s-%1$5s|%-4ls|", "a", "b"),
+				"%!(BADCFORMAT "+cReasonMixedArgs+")%!(EXTRA string=a, string=b)"; got != want {
+				t.Errorf("%d: mismatch result: want <%s>, got <%s>", threshold, want, got)
+			}
+
+			if got, want := SprintfC("%2$s-%1$5s|%2$-4ls|", "a", "b"), "b-    a|b   |"; got != want {
+				t.Errorf("%d: mismatch result: want <%s>, got <%s>", threshold, want, got)
+			}
+
+			if got, want := SprintfCA("%lld|%-6.2lf|%zu|%ls", Int(-5), Float(1.234), Uint(7), Str("s")),
+				"-5|1.23  |7|s"; got != want {
+				t.Errorf("%d: SprintfCA mismatch result: want <%s>, got <%s>", threshold, want, got)
+			}
+
+			// C chars are integer args
+			if got, want := SprintfCA("%c|%-3C|%lc|%c", Int('b'), Uint('x'), Int('ж'), Int(-1)),
+				"b|x  |ж|\uFFFD"; got != want {
+				t.Errorf("%d: SprintfCA mismatch result: want <%s>, got <%s>", threshold, want, got)
+			}
+
+			if got, want := SprintfC("%n", "a"), "%!(BADCFORMAT "+cReasonWrite+")%!(EXTRA string=a)"; got != want {
+				t.Errorf("%d: mismatch result: want <%s>, got <%s>", threshold, want, got)
+			}
+		}
+	}
+}
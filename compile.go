@@ -0,0 +1,197 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import "unsafe"
+
+// INFO: compiled formats
+//      Interpreter loop in (*xfmt).bprint() and (*token).format() re-checks `verbNone`, indirect width/prec flags,
+//      arg bounds and dispatches through a `switch` on every call for every token. Compilation turns the parsed
+//      format into a chain of small specialized funcs with all static decisions resolved at compile time. The only
+//      dynamic check, args count, is done once per call for the whole chain: if there are enough args, all of
+//      the arg indexes used by the format are in bounds, otherwise the interpreter loop is used as a fallback
+//      Compilation allocates closures, so it is done only for formats stored in the cache
+
+// fmtStep is a single compiled step of the format
+// WARN `args` always has at least `xfmt.args` items
+type fmtStep = func(buf *buffer, args []string)
+
+type fmtChain []fmtStep
+
+// NOTE calls of func values make `args` escape to heap (compiler knows nothing about the callee), that leads to
+//      memalloc of variadic args slice at every Sprintf call site, so `args` is hidden from escape analysis
+//      It is safe because steps never retain `args`
+//go:nosplit
+func (chain fmtChain) run(buf *buffer, args []string) {
+
+	hidden := *(*[]string)(noescape(unsafe.Pointer(&args)))
+
+	for i := 0; i < len(chain); i++ {
+		chain[i](buf, hidden)
+	}
+}
+
+// noescape hides a pointer from escape analysis
+// SEE src/runtime/stubs.go::noescape()
+// inlined
+//go:nosplit
+func noescape(p unsafe.Pointer) unsafe.Pointer {
+	x := uintptr(p)
+	return *(*unsafe.Pointer)(unsafe.Pointer(&x))
+}
+
+// compile returns nil for formats without tokens or with named placeholders (their out of bounds arg indexes
+// are checked only by the interpreter) or with clauses (message formats are rendered by their own interpreter)
+func (fmt *xfmt) compile() (chain fmtChain) {
+
+	if (len(fmt.tokens) == 0) || (len(fmt.names) != 0) || (len(fmt.clauses) != 0) {
+		return nil
+	}
+
+	return compileTokens(fmt.tokens)
+}
+
+// compileTokens compiles tokens, conditional groups are compiled into single steps, SEE group.go
+func compileTokens(tokens []token) (chain fmtChain) {
+
+	chain = make(fmtChain, 0, len(tokens))
+
+	for i := 0; i < len(tokens); i++ {
+
+		if tokens[i].verb == verbGroup {
+			chain = append(chain, compileGroup(groupOf(tokens, i)))
+			i += int(tokens[i].width)
+			continue
+		}
+
+		chain = append(chain, tokens[i].compile())
+	}
+
+	return chain
+}
+
+func (token *token) compile() fmtStep {
+
+	// simple case - solely raw string const value
+	if token.verb == verbNone {
+
+		value := token.value
+
+		return func(buf *buffer, _ []string) {
+			buf.WriteString(value)
+		}
+	}
+
+	arg, flags, width, prec := uint(token.arg), token.flags, int(token.width), int(token.prec)
+
+	// indirect width and prec always write errors and bad verb always writes error, so they are rare and go
+	// through the interpreter as well as verbs with default values
+	if flags.omit(flagIndirectWidth|flagIndirectPrec) && !token.hasDefault() {
+		switch token.verb {
+		case verbString:
+			return compileStr(arg, flags, width, prec)
+		case verbQuoted:
+			return compileQuot(arg, flags, width, prec)
+		case verbHex:
+			return compileHex(arg, flags, width, prec)
+		}
+	}
+
+	// copy token to not to depend on the tokens slice
+	tok := *token
+
+	return func(buf *buffer, args []string) {
+		tok.format(buf, args)
+	}
+}
+
+// SEE fmtStr()
+//go:nosplit
+func compileStr(arg uint, flags flags, width, prec int) fmtStep {
+
+	switch {
+	// plain `%s`
+	case (width <= 0) && (prec == absentValue):
+		return func(buf *buffer, args []string) {
+			buf.WriteString(args[arg])
+		}
+	// padded `%10s`
+	case prec == absentValue:
+		return func(buf *buffer, args []string) {
+			padString(buf, args[arg], flags, width)
+		}
+	}
+
+	return func(buf *buffer, args []string) {
+		fmtStr(buf, args[arg], flags, width, prec)
+	}
+}
+
+// SEE fmtQuot()
+//go:nosplit
+func compileQuot(arg uint, flags flags, width, prec int) fmtStep {
+
+	// backquoted `%#q` depends on arg value
+	if flags.omit(flagAltFmt) && (width <= 0) && (prec == absentValue) {
+
+		// plain `%q` and `%+q`
+		asciiOnly := flags.has(flagAsciiOnly)
+
+		return func(buf *buffer, args []string) {
+			b, _ := appendQuote(buf.TempBuf(), args[arg], asciiOnly)
+			buf.Write(b)
+		}
+	}
+
+	return func(buf *buffer, args []string) {
+		fmtQuot(buf, args[arg], flags, width, prec)
+	}
+}
+
+// SEE fmtHex()
+//go:nosplit
+func compileHex(arg uint, flags flags, width, prec int) fmtStep {
+
+	// plain `%x` and `%X`
+	if flags.omit(flagWithSpace|flagAltFmt) && (width <= 0) && (prec == absentValue) {
+
+		digits := ldigits
+
+		if flags.has(flagUpperVerb) {
+			digits = udigits
+		}
+
+		return func(buf *buffer, args []string) {
+
+			s := args[arg]
+
+			raw := buf.Advance(2 * len(s))
+
+			for i, j := 0, uint(0); (i < len(s)) && (j+1 < uint(len(raw))); i, j = i+1, j+2 {
+				c := s[i]
+				raw[j] = digits[c>>4]     // high
+				raw[j+1] = digits[c&0x0F] // low
+			}
+		}
+	}
+
+	return func(buf *buffer, args []string) {
+		fmtHex(buf, args[arg], flags, width, prec)
+	}
+}
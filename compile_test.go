@@ -0,0 +1,111 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"testing"
+)
+
+// go test -count=1 -v -run "^TestCompiledChain$"
+func TestCompiledChain(t *testing.T) {
+
+	check := func(format string, args []string) {
+
+		x := parseFormat(format)
+
+		want := x.Sprint(args)
+
+		x.chain = x.compile()
+
+		if x.chain == nil && len(x.tokens) != 0 {
+			t.Fatalf("%q: format has not been compiled", format)
+		}
+
+		if got := x.Sprint(args); got != want {
+			t.Errorf("%q %q: compiled result mismatch with interpreter: want <%s>, got <%s>", format, args, want, got)
+		}
+	}
+
+	for i := range adaptedFmtTestCases1 {
+		check(adaptedFmtTestCases1[i].format, []string{adaptedFmtTestCases1[i].arg})
+	}
+
+	for i := range adaptedFmtReorderTestCases1 {
+		check(adaptedFmtReorderTestCases1[i].format, adaptedFmtReorderTestCases1[i].args)
+	}
+
+	for i := range adaptedFmtIndirTestCases1 {
+		check(adaptedFmtIndirTestCases1[i].format, adaptedFmtIndirTestCases1[i].args)
+	}
+
+	for i := range linearBenchList {
+		check(linearBenchList[i].format, linearBenchList[i].args)
+	}
+}
+
+// go test -count=1 -v -run "^TestCompiledCache$"
+func TestCompiledCache(t *testing.T) {
+
+	defer SetCacheThreshold(CacheThreshold())
+
+	purgeCaches()
+
+	SetCacheThreshold(CacheAlways)
+
+	const format = "compiled %s %q %x"
+
+	if want, got := "compiled a \"b\" 63", Sprintf(format, "a", "b", "c"); got != want {
+		t.Fatalf("result mismatch: want <%s>, got <%s>", want, got)
+	}
+
+	if x, has := xfmtCache.Get(formatKey{format: format}); !has || x.chain == nil {
+		t.Fatal("cached format has not been compiled")
+	}
+
+	// not enough args - interpreter fallback
+	if want, got := "compiled a %!q(MISSING) %!x(MISSING)", Sprintf(format, "a"); got != want {
+		t.Fatalf("result mismatch: want <%s>, got <%s>", want, got)
+	}
+}
+
+// go test -bench "^BenchmarkCompiledChain$" -run "^$" -benchmem
+func BenchmarkCompiledChain(b *testing.B) {
+
+	for i := range linearBenchList {
+
+		e := &linearBenchList[i]
+
+		x := parseFormat(e.format)
+
+		b.Run(e.name+"/interp", func(bb *testing.B) {
+			for i := 0; i < bb.N; i++ {
+				x.bprint(e.args).Free()
+			}
+		})
+
+		c := x
+		c.chain = c.compile()
+
+		b.Run(e.name+"/chain", func(bb *testing.B) {
+			for i := 0; i < bb.N; i++ {
+				c.bprint(e.args).Free()
+			}
+		})
+	}
+}
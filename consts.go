@@ -24,16 +24,31 @@ const (
 	percentString     = "%"
 	commaSpaceString  = ", "
 	reflectStringType = "string" // avoid import reflect package
+	reflectInt64Type  = "int64"
+	reflectUint64Type = "uint64"
+	reflectFloatType  = "float64"
+	reflectBoolType   = "bool"
 	percentBangString = "%!"
 	missingString     = "(MISSING)"
+	missingNameString = "(MISSING "
 	badIndexString    = "(BADINDEX)"
+	nilAngleString    = "<nil>"
+	nilParenString    = "(" + nilAngleString + ")"
+
+	// arg types without reflect (dynamic types of interface values are unknown)
+	reflectFloat32Type = "float32"
+	appenderType       = "xfmt.Appender"
+	errorType          = "error"
+	stringerType       = "fmt.Stringer"
+	unsupportedType    = "?"
 
 	// format errors
-	extraString    = "%!(EXTRA "
-	badWidthString = "%!(BADWIDTH)"
-	badPrecString  = "%!(BADPREC)"
-	noVerbString   = "%!(NOVERB)"
-	nilToken       = "%!(NILTOKEN)"
+	extraString      = "%!(EXTRA "
+	badWidthString   = "%!(BADWIDTH)"
+	badPrecString    = "%!(BADPREC)"
+	noVerbString     = "%!(NOVERB)"
+	badCFormatString = "%!(BADCFORMAT "
+	nilToken         = "%!(NILTOKEN)"
 )
 
 // chars aliases
@@ -42,6 +57,8 @@ const (
 	charPercent     = '%'
 	charOpenArgNum  = '['
 	charCloseArgNum = ']'
+	charOpenName    = '{'
+	charCloseName   = '}'
 	charAsterisk    = '*'
 	charDot         = '.'
 	charLeftParens  = '(' // parenthesis
@@ -49,13 +66,17 @@ const (
 	charSpace       = ' '
 	charZero        = '0'
 	charBackquote   = '`'
+	charDoubleQuote = '"'
+	charBackslash   = '\\'
 )
 
 // const strings
 const (
+	equalsStr        = string(charEquals)
 	percentStr       = string(charPercent)
 	doublePercentStr = percentStr + percentStr
 	backquoteStr     = string(charBackquote)
+	rightParensStr   = string(charRightParens)
 
 	emptyString = ""
 )
@@ -0,0 +1,105 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import "unicode/utf8"
+
+// INFO: default values
+//      Verb with default value "%|n/a|s" prints the default instead of empty arg, so results like "user= id=" become
+//      "user=n/a id=n/a". The default goes right after '%' before flags, name, width, precision and arg index
+//      ("%|-|-10s", "%|none|{reason}q", "%|0|[2]x") and is formatted by the verb as the arg itself. The default can't
+//      contain '|' and '%', so "%|" without the closing pipe before the next '%' is the bad verb as before. Missing
+//      args are still printed as errors. Token value of such verb is the slice of the format from the opening '|' to
+//      the verb inclusive ("|n/a|-10s"), so neither memallocs nor additional token fields are needed: the default is
+//      between the pipes and the verb is the last char
+
+const (
+	charDefault = '|'
+
+	// chars that end default value, only the closing pipe is proper one
+	defaultStops = "|%"
+)
+
+// hasDefault reports whether the verb token has default value
+// inlined
+//go:nosplit
+func (token *token) hasDefault() bool {
+	// NOTE token value of `%|` bad verb without default is exactly "|", raw const string values have no defaults
+	return (token.verb != verbNone) && (len(token.value) > 1) && (token.value[0] == charDefault)
+}
+
+// verbString returns verb char of the verb token
+// inlined
+//go:nosplit
+func (token *token) verbString() string {
+
+	if !token.hasDefault() {
+		return token.value
+	}
+
+	value := token.value
+
+	if c := value[len(value)-1]; c < utf8.RuneSelf {
+		return value[len(value)-1:]
+	}
+
+	_, size := utf8.DecodeLastRuneInString(value)
+
+	return value[len(value)-size:]
+}
+
+// defaultValue returns default value of the verb token, SEE hasDefault()
+// inlined
+//go:nosplit
+func (token *token) defaultValue() string {
+
+	// here the closing pipe always exists
+	for i := 1; i < len(token.value); i++ {
+		if token.value[i] == charDefault {
+			return token.value[1:i]
+		}
+	}
+
+	return ""
+}
+
+// withDefault returns default value of the verb token instead of empty arg
+// inlined
+//go:nosplit
+func (token *token) withDefault(arg string) string {
+
+	if (arg == "") && token.hasDefault() {
+		return token.defaultValue()
+	}
+
+	return arg
+}
+
+// verbValueStart returns start of token value of the verb at i, def is the index of the closing pipe of default
+// value, 0 if there is no default
+// inlined
+//go:nosplit
+func verbValueStart(i, def uint) uint {
+
+	if def != 0 {
+		return 1
+	}
+
+	return i
+}
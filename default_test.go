@@ -0,0 +1,132 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// go test -count=1 -v -run "^TestDefaultValues$"
+func TestDefaultValues(t *testing.T) {
+
+	cases := [...]struct {
+		format string
+		args   []string
+		want   string
+	}{
+		{"user=%|n/a|s id=%|n/a|s", []string{"", "7"}, "user=n/a id=7"},
+		{"user=%|n/a|s id=%|n/a|s", []string{"bob", ""}, "user=bob id=n/a"},
+		{"%|-|-5s|%|-|5s|%|-|.1s", []string{"", "", ""}, "-    |    -|-"},
+		{"%|none|q %|none|x %|none|X %|none|v", []string{"", "", "", ""}, `"none" 6e6f6e65 6E6F6E65 none`},
+		{"%|none|#q", []string{""}, "`none`"},
+		{"%|x|[2]s %|y|[1]s", []string{"", ""}, "x y"},
+		{"%|n/a|10.2s|", []string{""}, "        n/|"},
+		{"%|абв|s", []string{""}, "абв"},
+		{"%||s", []string{""}, ""},
+
+		// errors keep the verb only
+		{"%|n/a|s", nil, "%!s(MISSING)"},
+		{"%|n/a|z", []string{""}, "%!z(string=n/a)"},
+		{"%|n/a|ж", []string{"a"}, "%!ж(string=a)"},
+		{"%|n/a|*s", []string{"1", ""}, "%!(BADWIDTH)n/a"},
+		{"%|n/a|", nil, "%!(NOVERB)"},
+
+		// unterminated default is bad verb `%|` as before
+		{"%|", []string{"a"}, "%!|(string=a)"},
+		{"%|n/a", []string{"a"}, "%!|(string=a)n/a"},
+
+		// the default can't contain '%', so the closing pipe isn't searched past the next verb
+		{"%|x %s | y", []string{"a", "b"}, "%!|(string=a)x b | y"},
+		{"%|100%|s", []string{""}, "%!|(string=)100%!|(MISSING)s"},
+	}
+
+	defer SetCacheThreshold(CacheThreshold())
+
+	for _, threshold := range [...]uint{CacheAlways, CacheRepetitions, CacheDisabled} {
+
+		purgeCaches()
+
+		SetCacheThreshold(threshold)
+
+		for i := 0; i < 3; i++ {
+			for _, c := range cases {
+
+				if got := Sprintf(c.format, c.args...); got != c.want {
+					t.Errorf("%d: %q %q: mismatch result: want <%s>, got <%s>", threshold, c.format, c.args, c.want, got)
+				}
+
+				var buf bytes.Buffer
+
+				xfmt := forgeXfmt(c.format)
+
+				if _, err := xfmt.Fstream(&buf, c.args); (err != nil) || (buf.String() != c.want) {
+					t.Errorf("%d: %q %q: Fstream mismatch result: want <%s>, got <%s> (err: %v)", threshold, c.format,
+						c.args, c.want, buf.String(), err)
+				}
+			}
+		}
+	}
+}
+
+// go test -count=1 -v -run "^TestDefaultValuesArgs$"
+func TestDefaultValuesArgs(t *testing.T) {
+
+	cases := [...]struct {
+		format string
+		args   []Arg
+		want   string
+	}{
+		{"%|n/a|s %|n/a|v %|n/a|5s", []Arg{Str(""), Bytes(nil), Err(nil)}, "n/a n/a   n/a"},
+		{"%|n/a|d %|n/a|t", []Arg{Int(0), Bool(false)}, "0 false"},
+		{"%|n/a|v", []Arg{Err(errors.New("failed"))}, "failed"},
+		{"%|n/a|d", []Arg{Str("")}, "%!d(string=n/a)"},
+
+		// raw const strings starting with pipe are not defaults
+		{"|x| %s", []Arg{Str("v")}, "|x| v"},
+		{"|x|%|n/a|s|", []Arg{Str("")}, "|x|n/a|"},
+	}
+
+	for _, c := range cases {
+		if got := SprintfA(c.format, c.args...); got != c.want {
+			t.Errorf("%q: mismatch result: want <%s>, got <%s>", c.format, c.want, got)
+		}
+	}
+
+	args := map[string]string{"user": "", "host": "example.com"}
+
+	if got, want := SprintfMap("%|anonymous|{user}s@%|-|{host}s %|-|{port}s", args),
+		"anonymous@example.com %!s(MISSING port)"; got != want {
+		t.Errorf("SprintfMap mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	var buf bytes.Buffer
+
+	long := strings.Repeat("x", vecThreshold)
+
+	if _, _ = FprintfVec(&buf, "%s %|n/a|s", long, ""); buf.String() != long+" n/a" {
+		t.Errorf("FprintfVec mismatch result")
+	}
+
+	assertMallocs(t, "FprintfA with defaults", 0, func() {
+		_, _ = FprintfA(mallocWriter{}, "user=%|n/a|s id=%|n/a|d", Str(""), Int(0))
+	})
+}
@@ -0,0 +1,386 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// INFO: shell-style expansion
+//      Templates with "$NAME", "${NAME}", "${NAME:-default}", "${NAME-default}", "${NAME:?error}" and
+//      "${NAME?error}" are parsed by separate frontend into named placeholders (SEE named.go), so they share rendering
+//      engine and caching with printf-like formats. Optional xfmt verb goes right after the name: "${NAME%q}",
+//      "${NAME%-10s:-none}". Like in shell, unset vars are expanded to empty strings, default words and error
+//      messages are literal (not expanded) and "$$" is escaped "$"
+
+const (
+	charDollar   = '$'
+	charEnvColon = ':'
+	charEnvUnset = '-'
+	charEnvError = '?'
+
+	envNullErrorString  = "parameter null or not set"
+	envUnsetErrorString = "parameter not set"
+	envBadString        = "bad substitution"
+)
+
+// envOp is the operation of expansion applied to the value
+type envOp uint8
+
+const (
+	envOpNone         envOp = iota // "${NAME}"
+	envOpDefault                   // "${NAME:-word}" - word if unset or empty
+	envOpDefaultUnset              // "${NAME-word}" - word if unset
+	envOpError                     // "${NAME:?word}" - error if unset or empty
+	envOpErrorUnset                // "${NAME?word}" - error if unset
+	envOpBad                       // malformed expansion, always error
+)
+
+// nameExt is the extension of the named placeholder
+type nameExt struct {
+	word string // default value or error message
+	op   envOp
+}
+
+// ExpandError is the error of expansion: either "${NAME:?error}" for unset (or empty) var or malformed expansion
+type ExpandError struct {
+	Name    string // var name or the whole malformed expansion
+	Message string
+}
+
+func (e *ExpandError) Error() string {
+	return "xfmt: " + e.Name + ": " + e.Message
+}
+
+var envFrontend = frontend{
+	syntax: syntaxEnv,
+	parse:  parseEnvFormat,
+}
+
+// NOTE every expansion has its own name (even repeated one), because extensions of the same name may differ
+// NOTE retval by value
+func parseEnvFormat(template string) xfmt {
+
+	minSize := 0
+
+	var (
+		tokens []token
+		lit    literal
+		names  []string
+		ext    []nameExt
+	)
+
+	for pos := 0; pos < len(template); {
+
+		i := strings.IndexByte(template[pos:], charDollar)
+
+		// if '$' not found - grab all existing tail
+		if i == -1 {
+			i = len(template) - pos
+		}
+
+		if i > 0 {
+
+			tokens = appendLiteral(tokens, &lit, template, pos, pos+i)
+
+			minSize += i
+
+			if pos += i; pos == len(template) {
+				break
+			}
+		}
+
+		// here template[pos] is '$'
+
+		var c byte
+
+		if pos+1 < len(template) {
+			c = template[pos+1]
+		}
+
+		var (
+			name string
+			tok  = envDefaultToken
+			e    nameExt
+		)
+
+		switch {
+		// escaped "$$" is a single '$' merged with adjacent raw const string values like "%%"
+		case c == charDollar:
+			tokens = appendPercent(tokens, &lit, template, pos, 1)
+
+			minSize++
+			pos += 2
+
+			continue
+
+		// "$NAME"
+		case isEnvNameStart(c):
+			end := envNameEnd(template, pos+1)
+
+			name, pos = template[pos+1:end], end
+
+		// "${...}"
+		case c == charOpenName:
+			end := strings.IndexByte(template[pos+2:], charCloseName)
+
+			// unfinished expansion is malformed one with the whole tail
+			if end == -1 {
+				name, e = template[pos:], nameExt{op: envOpBad}
+				pos = len(template)
+				break
+			}
+
+			name, tok, e = parseEnvExpansion(template[pos+2 : pos+2+end])
+
+			// malformed expansion is reported as a whole
+			if e.op == envOpBad {
+				name = template[pos : pos+2+end+1]
+			}
+
+			pos += 2 + end + 1
+
+		// lonely '$' is just a char
+		default:
+			tokens = appendLiteral(tokens, &lit, template, pos, pos+1)
+
+			minSize++
+			pos++
+
+			continue
+		}
+
+		tok.arg = namedArgBase + uint32(len(names))
+
+		names = append(names, name)
+		ext = append(ext, e)
+
+		tokens = append(tokens, tok)
+		lit.open = false
+	}
+
+	return xfmt{
+		tokens:  tokens,
+		minSize: minSize,
+		names:   names,
+		ext:     ext,
+	}
+}
+
+// token of expansion without explicit verb
+var envDefaultToken = token{
+	verb:  verbString,
+	value: verbStringString,
+	width: absentValue,
+	prec:  absentValue,
+}
+
+// parseEnvExpansion parses `NAME[%verb][op word]` of "${...}" expansion
+//go:nosplit
+func parseEnvExpansion(s string) (name string, tok token, e nameExt) {
+
+	tok = envDefaultToken
+
+	if (s == "") || !isEnvNameStart(s[0]) {
+		return s, tok, nameExt{op: envOpBad}
+	}
+
+	i := envNameEnd(s, 0)
+
+	name = s[:i]
+
+	// optional verb "%-10.3q"
+	if (i < len(s)) && (s[i] == charPercent) {
+
+		j := i + 1
+
+		for ; (j < len(s)) && (s[j] < utf8.RuneSelf) && ((charFlags[s[j]] != flagNone) || isDigit(s[j]) ||
+			(s[j] == charDot)); j++ {
+		}
+
+		// verb is a letter
+		if (j >= len(s)) || (s[j] >= utf8.RuneSelf) || (charVerbs[s[j]] == verbNone) {
+			return name, tok, nameExt{op: envOpBad}
+		}
+
+		tokens := parseFormat(s[i : j+1]).tokens
+
+		if (len(tokens) != 1) || (tokens[0].verb == verbNone) {
+			return name, tok, nameExt{op: envOpBad}
+		}
+
+		tok, i = tokens[0], j+1
+	}
+
+	// op and word
+	if s = s[i:]; s == "" {
+		return name, tok, e
+	}
+
+	colon := s[0] == charEnvColon
+
+	if colon {
+		s = s[1:]
+	}
+
+	if s == "" {
+		return name, tok, nameExt{op: envOpBad}
+	}
+
+	switch e.word = s[1:]; s[0] {
+	case charEnvUnset:
+		e.op = envOpDefaultUnset
+	case charEnvError:
+		e.op = envOpErrorUnset
+	default:
+		return name, tok, nameExt{op: envOpBad}
+	}
+
+	// ":-" and ":?" are the next ops after "-" and "?"
+	if colon {
+		e.op--
+	}
+
+	return name, tok, e
+}
+
+// inlined
+//go:nosplit
+func isEnvNameStart(c byte) bool {
+	return (('a' <= c) && (c <= 'z')) || (('A' <= c) && (c <= 'Z')) || (c == '_')
+}
+
+// inlined
+//go:nosplit
+func isDigit(c byte) bool {
+	return ('0' <= c) && (c <= '9')
+}
+
+// envNameEnd returns index of the first char after the name starting at s[i]
+//go:nosplit
+func envNameEnd(s string, i int) int {
+
+	for ; (i < len(s)) && (isEnvNameStart(s[i]) || isDigit(s[i])); i++ {
+	}
+
+	return i
+}
+
+// bprintExpandTo is bprintNamedTo with extensions of expansions
+// SEE (*xfmt).bprintNamedTo()
+func (fmt *xfmt) bprintExpandTo(buf *buffer, args namedArgs) error {
+
+	// try to minimize memallocs
+	buf.Grow(fmt.minSize)
+
+	// arg of the current expansion
+	var arg [1]string
+
+	for i := 0; i < len(fmt.tokens); i++ {
+
+		token := &fmt.tokens[i]
+
+		if token.verb == verbNone {
+			buf.WriteString(token.value)
+			continue
+		}
+
+		idx := token.arg - namedArgBase
+
+		name, e := fmt.names[idx], &fmt.ext[idx]
+
+		if e.op == envOpBad {
+			return &ExpandError{Name: name, Message: envBadString}
+		}
+
+		// unset var is expanded to empty string
+		value, ok := args.lookup(name)
+
+		switch e.op {
+		case envOpDefault:
+			if value == "" {
+				value = e.word
+			}
+		case envOpDefaultUnset:
+			if !ok {
+				value = e.word
+			}
+		case envOpError:
+			if value == "" {
+				return e.error(name, envNullErrorString)
+			}
+		case envOpErrorUnset:
+			if !ok {
+				return e.error(name, envUnsetErrorString)
+			}
+		}
+
+		// expansion is formatted as positional verb with single arg
+		tok := *token
+		tok.arg, arg[0] = 0, value
+
+		tok.format(buf, arg[:])
+	}
+
+	return nil
+}
+
+// error returns ExpandError with message of "${NAME:?message}" or default one
+//go:nosplit
+func (e *nameExt) error(name, message string) error {
+
+	if e.word != "" {
+		message = e.word
+	}
+
+	return &ExpandError{Name: name, Message: message}
+}
+
+// Expand expands "$NAME" and "${NAME...}" expansions of template taking values from lookup, SEE ExpandError
+func Expand(template string, lookup func(name string) (string, bool)) (string, error) {
+
+	xfmt := envFrontend.forge(template, false)
+
+	// - template without expansions
+	if len(xfmt.names) == 0 {
+		return xfmt.Sprint(nil), nil
+	}
+
+	b := fmtprintbufpool.Get()
+
+	err := xfmt.bprintExpandTo(b, funcArgs(lookup))
+
+	s := ""
+
+	// WARN make string from buf BEFORE return buf to pool
+	if err == nil {
+		s = b.String()
+	}
+
+	b.Free()
+
+	return s, err
+}
+
+// ExpandEnv is Expand with values of environment variables
+func ExpandEnv(template string) (string, error) {
+	return Expand(template, os.LookupEnv)
+}
@@ -0,0 +1,174 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// go test -count=1 -v -run "^TestExpand$"
+func TestExpand(t *testing.T) {
+
+	defer SetCacheThreshold(CacheThreshold())
+
+	vars := map[string]string{"USER": "bob", "EMPTY": "", "HOST": "example.com", "GREET": "Привет"}
+
+	lookup := func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+
+	cases := [...]struct {
+		template string
+		want     string
+	}{
+		{"", ""},
+		{"plain", "plain"},
+		{"hi $USER@${HOST}!", "hi bob@example.com!"},
+		{"$USER$USER ${USER}_x $USER_x|", "bobbob bob_x |"},
+		{"[$NONE][${NONE}]", "[][]"},
+		{"${NONE:-def} ${EMPTY:-def} ${USER:-def}", "def def bob"},
+		{"[${NONE-def}][${EMPTY-def}]", "[def][]"},
+		{"[${EMPTY?}][${USER:?}]", "[][bob]"},
+		{"${USER%q} [${USER%-6s}] [${NONE%5s:-x}] ${GREET%.3s} ${USER%x}", `"bob" [bob   ] [    x] При 626f62`},
+		{"$$HOME $ $1 cost: 5$", "$HOME $ $1 cost: 5$"},
+		{"${USER%d}", "%!d(string=bob)"},
+	}
+
+	for _, threshold := range [...]uint{CacheAlways, CacheRepetitions, CacheDisabled} {
+
+		purgeCaches()
+
+		SetCacheThreshold(threshold)
+
+		for i := 0; i < 3; i++ {
+			for _, c := range cases {
+
+				got, err := Expand(c.template, lookup)
+
+				if err != nil {
+					t.Errorf("%d: %q: unexpected error: %v", threshold, c.template, err)
+					continue
+				}
+
+				if got != c.want {
+					t.Errorf("%d: %q: mismatch result: want <%s>, got <%s>", threshold, c.template, c.want, got)
+				}
+			}
+		}
+
+		if threshold == CacheDisabled {
+			continue
+		}
+
+		// the only memalloc is the result string
+		assertMallocs(t, "Expand", 1, func() {
+			_, _ = Expand("hi $USER@${HOST}!", lookup)
+		})
+	}
+}
+
+// go test -count=1 -v -run "^TestExpandErrors$"
+func TestExpandErrors(t *testing.T) {
+
+	lookup := func(name string) (string, bool) {
+		return "", name == "EMPTY"
+	}
+
+	cases := [...]struct {
+		template string
+		name     string
+		message  string
+	}{
+		{"${EMPTY:?must be set}", "EMPTY", "must be set"},
+		{"${EMPTY:?}", "EMPTY", envNullErrorString},
+		{"${NONE?}", "NONE", envUnsetErrorString},
+		{"ok ${NONE?no NONE}", "NONE", "no NONE"},
+		{"${}", "${}", envBadString},
+		{"${USER", "${USER", envBadString},
+		{"${USER:x}", "${USER:x}", envBadString},
+		{"${USER:}", "${USER:}", envBadString},
+		{"${1X}", "${1X}", envBadString},
+		{"${USER%Ж}", "${USER%Ж}", envBadString},
+		{"${USER%*s}", "${USER%*s}", envBadString},
+	}
+
+	for _, c := range cases {
+
+		s, err := Expand(c.template, lookup)
+
+		var eerr *ExpandError
+
+		if !errors.As(err, &eerr) {
+			t.Errorf("%q: unexpected error: %v (result <%s>)", c.template, err, s)
+			continue
+		}
+
+		if (eerr.Name != c.name) || (eerr.Message != c.message) {
+			t.Errorf("%q: mismatch error: want %s: %s, got %s: %s", c.template, c.name, c.message, eerr.Name,
+				eerr.Message)
+		}
+	}
+}
+
+// go test -count=1 -v -run "^TestExpandEnv$"
+func TestExpandEnv(t *testing.T) {
+
+	const name = "XFMT_TEST_EXPAND_ENV"
+
+	defer os.Unsetenv(name)
+
+	if err := os.Setenv(name, "value"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := ExpandEnv("[${" + name + "%7s}]"); (err != nil) || (got != "[  value]") {
+		t.Errorf("ExpandEnv mismatch result: got <%s> (err: %v)", got, err)
+	}
+}
+
+// go test -count=1 -v -run "^TestExpandSeparateCacheKeys$"
+func TestExpandSeparateCacheKeys(t *testing.T) {
+
+	defer SetCacheThreshold(CacheThreshold())
+
+	purgeCaches()
+
+	SetCacheThreshold(CacheAlways)
+
+	// env templates share the format cache with printf-like formats under their own keys
+	const template = "$A %s"
+
+	lookup := func(name string) (string, bool) {
+		return "x", true
+	}
+
+	for i := 0; i < 2; i++ {
+
+		if got, err := Expand(template, lookup); (err != nil) || (got != "x %s") {
+			t.Errorf("#%d: Expand mismatch result: got <%s> (err: %v)", i, got, err)
+		}
+
+		if got, want := Sprintf(template, "y"), "$A y"; got != want {
+			t.Errorf("#%d: Sprintf mismatch result: want <%s>, got <%s>", i, want, got)
+		}
+	}
+}
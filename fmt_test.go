@@ -413,6 +413,21 @@ func BenchmarkSprintfHexString(b *testing.B) {
 	doBenchmarkSprintf(b, "% #x", tftc1arg15str)
 }
 
+// go test -bench "^BenchmarkSprintfPaddedTruncateString" -run "^$"
+func BenchmarkSprintfPaddedTruncateString(b *testing.B) {
+	doBenchmarkSprintf(b, "%10.5s", tftc1arg10alphabet)
+}
+
+// go test -bench "^BenchmarkSprintfPaddedTruncateStringUTF8$" -run "^$"
+func BenchmarkSprintfPaddedTruncateStringUTF8(b *testing.B) {
+	doBenchmarkSprintf(b, "%10.5s", tftc1arg14utf8cjklong)
+}
+
+// go test -bench "^BenchmarkSprintfPaddedQuoteString$" -run "^$"
+func BenchmarkSprintfPaddedQuoteString(b *testing.B) {
+	doBenchmarkSprintf(b, "%-40.20q", tftc1arg10alphabet)
+}
+
 // go test -bench "^BenchmarkManyArgs$" -run "^$"
 func BenchmarkManyArgs(b *testing.B) {
 	b.RunParallel(func(pb *testing.PB) {
@@ -452,6 +467,20 @@ var mallocTestCases1 = [...]mallocTestCase{
 	}},
 }
 
+// assertMallocs checks that fn makes no more than max memallocs per run
+func assertMallocs(t *testing.T, desc string, max uint, fn func()) {
+
+	t.Helper()
+
+	if raceEnabled {
+		return
+	}
+
+	if got := testing.AllocsPerRun(100, fn); got > float64(max) {
+		t.Errorf("%s: got %v allocs, want <= %v", desc, got, max)
+	}
+}
+
 // go test -count=1 -v -run "^TestAssertMallocs$"
 func TestAssertMallocs(t *testing.T) {
 
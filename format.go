@@ -24,8 +24,12 @@ import (
 
 type xfmt struct {
 	tokens  []token
-	args    uint // needed args count, may differ from len(tokens) due to [n] notation
-	minSize int  // minimal size of result string if all of fmt args are empty strings (== "")
+	args    uint      // needed args count, may differ from len(tokens) due to [n] notation
+	minSize int       // minimal size of result string if all of fmt args are empty strings (== "")
+	chain   fmtChain  // compiled tokens, nil if not compiled
+	names   []string  // names of named placeholders (`%{name}s`), nil if there are no ones, SEE named.go
+	ext     []nameExt // extensions of named placeholders (parallel to names), nil if there are no ones, SEE env.go
+	clauses []clause  // plural and select clauses of message formats, nil if there are no ones, SEE message.go
 	// NOTE small size struct, may be passed by value
 }
 
@@ -41,6 +45,14 @@ func (fmt *xfmt) bprint(args []string) (buf *buffer) {
 
 	buf = fmtprintbufpool.Get()
 
+	fmt.bprintTo(buf, args)
+
+	return buf
+}
+
+// bprintTo writes result to buf
+func (fmt *xfmt) bprintTo(buf *buffer, args []string) {
+
 	// try to minimize memallocs
 	buf.Grow(fmt.minSize)
 
@@ -52,30 +64,80 @@ func (fmt *xfmt) bprint(args []string) (buf *buffer) {
 		return
 	}
 
-	for i := 0; i < len(fmt.tokens); i++ {
-		fmt.tokens[i].format(buf, args)
+	// compiled chain requires enough args, so all of the arg indexes used by the format are in bounds
+	if (fmt.chain != nil) && (uint(len(args)) >= fmt.args) {
+		fmt.chain.run(buf, args)
+	} else {
+		for i := 0; i < len(fmt.tokens); i++ {
+
+			if fmt.tokens[i].verb == verbGroup {
+				i += skipGroup(fmt.tokens, i, args)
+				continue
+			}
+
+			fmt.tokens[i].format(buf, args)
+		}
+	}
+
+	if uint(len(args)) > fmt.args {
+		writeExtra(buf, args, fmt.args)
+	}
+}
+
+// Append appends result to dst and returns the extended slice
+func (fmt *xfmt) Append(dst []byte, args []string) []byte {
+
+	// fast-paths
+	// - format is empty string and no args
+	if (len(fmt.tokens) == 0) && (len(args) == 0) {
+		return dst
 	}
 
+	buf := fmtprintbufpool.Get()
+
+	// temporarily replace pooled bakary by dst, so result is written directly to dst without copying
+	own := buf.buf
+	buf.buf = dst
+
+	fmt.bprintTo(buf, args)
+
+	dst, buf.buf = buf.buf, own
+
+	buf.Free()
+
+	return dst
+}
+
+// common interface of buffer, streamer and vectorizer for writing rare parts of the result (e.g. errors)
+type stringWriter interface {
+	WriteString(s string)
+}
+
+// writeExtra writes args, which are not used by the format, starting from `from`
+// SEE src/fmt/print.go::(*pp).doPrintf() `if !p.reordered && argNum < len(a)`
+func writeExtra(w stringWriter, args []string, from uint) {
+
 	// uint automagically helps BCE optimization without additional conds
-	if nArgs := uint(len(args)); nArgs > fmt.args {
+	nArgs := uint(len(args))
 
-		buf.WriteString(extraString)
+	if nArgs <= from {
+		return
+	}
 
-		for i := fmt.args; i < nArgs; i++ {
+	w.WriteString(extraString)
 
-			if i > fmt.args {
-				buf.WriteString(commaSpaceString)
-			}
+	for i := from; i < nArgs; i++ {
 
-			buf.WriteString(reflectStringType)
-			buf.WriteByte(charEquals)
-			buf.WriteString(args[i])
+		if i > from {
+			w.WriteString(commaSpaceString)
 		}
 
-		buf.WriteByte(charRightParens)
+		w.WriteString(reflectStringType)
+		w.WriteString(equalsStr)
+		w.WriteString(args[i])
 	}
 
-	return buf
+	w.WriteString(rightParensStr)
 }
 
 func (fmt *xfmt) Fprint(w io.Writer, args []string) (n int, err error) {
@@ -86,6 +148,11 @@ func (fmt *xfmt) Fprint(w io.Writer, args []string) (n int, err error) {
 		return 0, nil
 	}
 
+	// large result should be streamed instead of whole-message buffering
+	if fmt.minSize+sumLen(args) > streamThreshold {
+		return fmt.Fstream(w, args)
+	}
+
 	// common case, buf needed
 
 	// wrap around bprint
@@ -99,6 +99,11 @@ const (
 	// no verb
 	tpfc1fmtnv = "%[3]*.*"
 
+	// merged %%
+	tpfc1fmt_pp_merge1 = "100%% done: %s"
+	tpfc1fmt_pp_merge2 = "%%%%"
+	tpfc1fmt_pp_merge3 = "a%-%b%%%q%%c"
+
 	// badVerb
 	tpfc1fmtvb           = "z"
 	tpfc1fmt_b           = "%z"
@@ -164,34 +169,34 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 	{
 		emptyString,
 		xfmt{
-			nil, // nil for empty format string
-			0,
-			len(emptyString),
+			tokens:  nil, // nil for empty format string
+			args:    0,
+			minSize: len(emptyString),
 		},
 	},
 	// raw const string value
 	{
 		tpfc1rawstring,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbNone,
 					value: tpfc1rawstring,
 				},
 			},
-			0,
-			len(tpfc1rawstring),
+			args:    0,
+			minSize: len(tpfc1rawstring),
 		},
 	},
 	// no verb
 	{
 		tpfc1fmtnv,
 		xfmt{
-			[]token{
+			tokens: []token{
 				tokenErrNoVerb,
 			},
-			4,
-			0,
+			args:    4,
+			minSize: 0,
 		},
 	},
 	// badVerb
@@ -199,7 +204,7 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 	{
 		tpfc1fmt_b,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  badVerb,
 					value: tpfc1fmtvb,
@@ -208,15 +213,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					prec:  absentValue,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%[2]*.[3]*[1]z" badVerb with indir width and prec, and explicit indexing arg
 	{
 		tpfc1fmt_bi1_wi2_pi3,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  badVerb,
 					value: tpfc1fmtvb,
@@ -226,15 +231,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			3,
-			0,
+			args:    3,
+			minSize: 0,
 		},
 	},
 	// "%[2]*.[3]*z" badVerb with indir width and prec, and implicit indexing arg
 	{
 		tpfc1fmt_b_wi2_pi3,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  badVerb,
 					value: tpfc1fmtvb,
@@ -244,26 +249,26 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   4 - 1,
 				},
 			},
-			4,
-			0,
+			args:    4,
+			minSize: 0,
 		},
 	},
 	// "%%" token
 	{
 		tpfc1fmt_pp,
 		xfmt{
-			[]token{
+			tokens: []token{
 				tokenPercent,
 			},
-			0,
-			1,
+			args:    0,
+			minSize: 1,
 		},
 	},
 	// simple "%s" token
 	{
 		tpfc1fmt_s,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbString,
 					value: string(verbCharString),
@@ -272,15 +277,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// simple "%s" token with arg num
 	{
 		tpfc1fmt_si3,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbString,
 					value: string(verbCharString),
@@ -289,15 +294,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   3 - 1, // args are indexing from 1
 				},
 			},
-			3,
-			0,
+			args:    3,
+			minSize: 0,
 		},
 	},
 	// "%s" token with width
 	{
 		tpfc1fmt_s_w20,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbString,
 					value: string(verbCharString),
@@ -306,15 +311,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1, // args are indexing from 1
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%s" token with width and prec
 	{
 		tpfc1fmt_s_w20_p7,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbString,
 					value: string(verbCharString),
@@ -323,15 +328,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1, // args are indexing from 1
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%s" token with width, prec and flag
 	{
 		tpfc1fmt_s_w20_p7_fm,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbString,
 					value: string(verbCharString),
@@ -341,15 +346,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1, // args are indexing from 1
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%s" token with width, prec, flag and arg num verb
 	{
 		tpfc1fmt_si1_w20_p7_fm,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbString,
 					value: string(verbCharString),
@@ -359,15 +364,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   3 - 1, // args are indexing from 1
 				},
 			},
-			3,
-			0,
+			args:    3,
+			minSize: 0,
 		},
 	},
 	// "%s" token with indir width, prec with no value and  flag sharp
 	{
 		tpfc1fmt_s_wi1_p_fs,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbString,
 					value: string(verbCharString),
@@ -377,15 +382,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   2 - 1,
 				},
 			},
-			2,
-			0,
+			args:    2,
+			minSize: 0,
 		},
 	},
 	// "%s" token without width, with prec and right pad
 	{
 		tpfc1fmt_s_p7_fm,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbString,
 					value: string(verbCharString),
@@ -395,15 +400,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   0,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%s" token with prec indir and arg num
 	{
 		tpfc1fmt_si1_pi2,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbString,
 					value: string(verbCharString),
@@ -413,15 +418,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			2,
-			0,
+			args:    2,
+			minSize: 0,
 		},
 	},
 	// "%s" token with indir width, prec and arg num
 	{
 		tpfc1fmt_si1_wi2_pi3,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbString,
 					value: string(verbCharString),
@@ -431,15 +436,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			3,
-			0,
+			args:    3,
+			minSize: 0,
 		},
 	},
 	// simple "%q" token
 	{
 		tpfc1fmt_q,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbQuoted,
 					value: string(verbCharQuoted),
@@ -449,15 +454,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%q" token with width and prec
 	{
 		tpfc1fmt_q_w20_p13,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbQuoted,
 					value: string(verbCharQuoted),
@@ -467,15 +472,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%q" token with flag plus
 	{
 		tpfc1fmt_q_fp,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbQuoted,
 					value: string(verbCharQuoted),
@@ -485,15 +490,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%q" token with flag sharp
 	{
 		tpfc1fmt_q_fs,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbQuoted,
 					value: string(verbCharQuoted),
@@ -503,15 +508,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%q" token with plus and sharp flags
 	{
 		tpfc1fmt_q_fps,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbQuoted,
 					value: string(verbCharQuoted),
@@ -521,15 +526,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%x" token
 	{
 		tpfc1fmt_x,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbHex,
 					value: string(verbCharHex),
@@ -539,15 +544,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%x" token with width and prec
 	{
 		tpfc1fmt_x_w19_p13,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbHex,
 					value: string(verbCharHex),
@@ -557,15 +562,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%x" token with width, prec and minus flag
 	{
 		tpfc1fmt_x_w23_p7_fm,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbHex,
 					value: string(verbCharHex),
@@ -575,15 +580,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%x" token with space flag
 	{
 		tpfc1fmt_x_f_,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbHex,
 					value: string(verbCharHex),
@@ -593,15 +598,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%x" token with sharp flag
 	{
 		tpfc1fmt_x_fs,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbHex,
 					value: string(verbCharHex),
@@ -611,15 +616,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%x" token with space and sharp flags
 	{
 		tpfc1fmt_x_fs_,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbHex,
 					value: string(verbCharHex),
@@ -629,15 +634,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%x" token with width, prec, space and sharp flags
 	{
 		tpfc1fmt_x_w37_p5_fs_,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbHex,
 					value: string(verbCharHex),
@@ -647,15 +652,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%x" token with width, prec, space, minus and sharp flags
 	{
 		tpfc1fmt_x_w37_p5_fsm_,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbHex,
 					value: string(verbCharHex),
@@ -665,15 +670,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%X" token
 	{
 		tpfc1fmt_X,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbHex,
 					value: string(unicode.ToUpper(verbCharHex)),
@@ -683,15 +688,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%X" token with width and prec
 	{
 		tpfc1fmt_X_w19_p13,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbHex,
 					value: string(unicode.ToUpper(verbCharHex)),
@@ -701,15 +706,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%X" token with width, prec and minus flag
 	{
 		tpfc1fmt_X_w23_p7_fm,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbHex,
 					value: string(unicode.ToUpper(verbCharHex)),
@@ -719,15 +724,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%X" token with space flag
 	{
 		tpfc1fmt_X_f_,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbHex,
 					value: string(unicode.ToUpper(verbCharHex)),
@@ -737,15 +742,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%X" token with sharp flag
 	{
 		tpfc1fmt_X_fs,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbHex,
 					value: string(unicode.ToUpper(verbCharHex)),
@@ -755,15 +760,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%X" token with space and sharp flags
 	{
 		tpfc1fmt_X_fs_,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbHex,
 					value: string(unicode.ToUpper(verbCharHex)),
@@ -773,15 +778,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%X" token with width, prec, space and sharp flags
 	{
 		tpfc1fmt_X_w37_p5_fs_,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbHex,
 					value: string(unicode.ToUpper(verbCharHex)),
@@ -791,15 +796,15 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
 		},
 	},
 	// "%X" token with width, prec, space, minus and sharp flags
 	{
 		tpfc1fmt_X_w37_p5_fsm_,
 		xfmt{
-			[]token{
+			tokens: []token{
 				{
 					verb:  verbHex,
 					value: string(unicode.ToUpper(verbCharHex)),
@@ -809,8 +814,66 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 					arg:   1 - 1,
 				},
 			},
-			1,
-			0,
+			args:    1,
+			minSize: 0,
+		},
+	},
+	// merged raw const string values and "%%"
+	{
+		tpfc1fmt_pp_merge1,
+		xfmt{
+			tokens: []token{
+				{
+					verb:  verbNone,
+					value: "100% done: ",
+				},
+				{
+					verb:  verbString,
+					value: string(verbCharString),
+					width: absentValue,
+					prec:  absentValue,
+					arg:   1 - 1,
+				},
+			},
+			args:    1,
+			minSize: len("100% done: "),
+		},
+	},
+	{
+		tpfc1fmt_pp_merge2,
+		xfmt{
+			tokens: []token{
+				{
+					verb:  verbNone,
+					value: "%%",
+				},
+			},
+			args:    0,
+			minSize: 2,
+		},
+	},
+	{
+		tpfc1fmt_pp_merge3,
+		xfmt{
+			tokens: []token{
+				{
+					verb:  verbNone,
+					value: "a%b%",
+				},
+				{
+					verb:  verbQuoted,
+					value: string(verbCharQuoted),
+					width: absentValue,
+					prec:  absentValue,
+					arg:   1 - 1,
+				},
+				{
+					verb:  verbNone,
+					value: "%c",
+				},
+			},
+			args:    1,
+			minSize: len("a%b%%c"),
 		},
 	},
 	/*
@@ -818,13 +881,13 @@ var parseFormatTestCases1 = [...]parseFormatTestCase{
 		{
 			tpfc1fmt_si3,
 			xfmt{
-				[]token{
+				tokens: []token{
 					{
 
 					},
 				},
-				0,
-				0,
+				args: 0,
+				minSize: 0,
 			},
 		},
 	*/
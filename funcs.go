@@ -19,8 +19,11 @@
 package xfmt
 
 import (
+	"encoding/binary"
 	"io"
+	"strconv"
 	"unicode/utf8"
+	"unsafe"
 )
 
 // inlined
@@ -42,7 +45,19 @@ func tailln(w io.Writer) (n int, err error) {
 //go:nosplit
 func truncateTail(s string, n int) string {
 
-	i := uint(0) // uint automagically helps BCE optimization without additional conds
+	// string can't contain more runes than bytes
+	if n >= len(s) {
+		return s
+	}
+
+	if n <= 0 {
+		return s[:0]
+	}
+
+	// every ascii char is a single rune, so ascii prefix of first n bytes needs no decoding
+	i := uint(asciiPrefixLen(s[:n])) // uint automagically helps BCE optimization without additional conds
+
+	n -= int(i)
 
 	for ; i < uint(len(s)) && (n > 0); n-- {
 		_, w := utf8.DecodeRuneInString(s[i:])
@@ -51,3 +66,112 @@ func truncateTail(s string, n int) string {
 
 	return s[:i]
 }
+
+// asciiMask has the high bit of every byte in the word set
+const asciiMask = 0x8080808080808080
+
+// asciiPrefixLen returns length of the longest pure ascii prefix of `s`
+// NOTE checks 8 bytes per iteration
+//go:nosplit
+func asciiPrefixLen(s string) int {
+
+	b := stringBytes(s)
+
+	i := 0
+
+	for ; len(b)-i >= 8; i += 8 {
+		if binary.LittleEndian.Uint64(b[i:])&asciiMask != 0 {
+			break
+		}
+	}
+
+	for ; (i < len(b)) && (b[i] < utf8.RuneSelf); i++ {
+	}
+
+	return i
+}
+
+// runeCountInString is utf8.RuneCountInString with fast path for ascii data
+//go:nosplit
+func runeCountInString(s string) int {
+
+	n := asciiPrefixLen(s)
+
+	if n < len(s) {
+		n += utf8.RuneCountInString(s[n:])
+	}
+
+	return n
+}
+
+// quoteSafeChars marks ascii chars that strconv.Quote keeps as is
+var quoteSafeChars = makeQuoteSafeChars()
+
+func makeQuoteSafeChars() (table [utf8.RuneSelf]bool) {
+
+	for c := charSpace; c < utf8.RuneSelf-1 /* DEL */; c++ {
+		table[c] = true
+	}
+
+	table[charDoubleQuote] = false
+	table[charBackslash] = false
+
+	return table
+}
+
+// appendQuote appends double-quoted `s` to `b` like strconv.AppendQuote (strconv.AppendQuoteToASCII if
+// `asciiOnly` is set) and returns amount of runes in appended quoted string, computed while quoting, or
+// absentValue if the quoted string isn't pure ascii and its runes should be counted
+//go:nosplit
+func appendQuote(b []byte, s string, asciiOnly bool) ([]byte, int) {
+
+	i := 0
+
+	for ; (i < len(s)) && (s[i] < utf8.RuneSelf) && quoteSafeChars[s[i]]; i++ {
+	}
+
+	// fast path: the whole string is kept as is
+	if i == len(s) {
+		b = append(b, charDoubleQuote)
+		b = append(b, s...)
+		b = append(b, charDoubleQuote)
+
+		return b, len(s) + 2
+	}
+
+	start := len(b)
+
+	if asciiOnly {
+		b = strconv.AppendQuoteToASCII(b, s)
+		return b, len(b) - start
+	}
+
+	b = strconv.AppendQuote(b, s)
+
+	// all escape sequences are ascii
+	if asciiPrefixLen(s[i:]) == len(s)-i {
+		return b, len(b) - start
+	}
+
+	return b, absentValue
+}
+
+// stringBytes returns []byte that shares backing storage of `s` without copying
+// WARN result must never be modified (e.g. it may be in the read-only data section)
+// inlined
+//go:nosplit
+func stringBytes(s string) []byte {
+	return *(*[]byte)(unsafe.Pointer(&struct {
+		string
+		int
+	}{s, len(s)}))
+}
+
+// bytesString returns string that shares backing storage of `b` without copying
+// WARN `b` must not be modified while result is in use
+// SEE src/strings/builder.go::(*Builder).String()
+// inlined
+//go:nosplit
+func bytesString(b []byte) string {
+	return *(*string)(unsafe.Pointer(&b))
+}
@@ -0,0 +1,97 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"io"
+	"unsafe"
+)
+
+// INFO: generic entry points
+//      All fns take `...string` args, so values of typed string kinds (`type UserID string`) had to be converted at
+//      every call site. Generic `*T` versions accept any ~string kind directly. Since a slice of any ~string kind
+//      has exactly the same memory layout as []string, args are passed to the plain versions without copying (and
+//      without `reflect` or interface boxing)
+
+// Strings returns args of any string kind as []string, sharing the same backing storage without copying
+// NOTE changes of the result are visible through `args` (and vice versa)
+// inlined
+//go:nosplit
+func Strings[S ~string](args []S) []string {
+	return *(*[]string)(unsafe.Pointer(&args))
+}
+
+// AsString converts a value of any string kind to string, it is the entry point for mixing args of different string
+// kinds in a single call, e.g. `Sprintf("%s: %s", AsString(uid), AsString(path))` or `SprintfT(format, uid, UserID(s))`
+// NOTE all args of a single *T call have the same string kind, so args of different kinds go to plain versions
+// inlined
+//go:nosplit
+func AsString[S ~string](s S) string {
+	return string(s)
+}
+
+// FprintT is Fprint for args of any string kind
+func FprintT[S ~string](w io.Writer, s ...S) (n int, err error) {
+	return Fprint(w, Strings(s)...)
+}
+
+// PrintT is Print for args of any string kind
+func PrintT[S ~string](s ...S) (n int, err error) {
+	return Print(Strings(s)...)
+}
+
+// SprintT is Sprint for args of any string kind
+func SprintT[S ~string](s ...S) string {
+	return Sprint(Strings(s)...)
+}
+
+// FprintlnT is Fprintln for args of any string kind
+func FprintlnT[S ~string](w io.Writer, s ...S) (n int, err error) {
+	return Fprintln(w, Strings(s)...)
+}
+
+// PrintlnT is Println for args of any string kind
+func PrintlnT[S ~string](s ...S) (n int, err error) {
+	return Println(Strings(s)...)
+}
+
+// SprintlnT is Sprintln for args of any string kind
+func SprintlnT[S ~string](s ...S) string {
+	return Sprintln(Strings(s)...)
+}
+
+// FprintfT is Fprintf for args of any string kind
+func FprintfT[S ~string](w io.Writer, format string, args ...S) (n int, err error) {
+	return Fprintf(w, format, Strings(args)...)
+}
+
+// PrintfT is Printf for args of any string kind
+func PrintfT[S ~string](format string, args ...S) (n int, err error) {
+	return Printf(format, Strings(args)...)
+}
+
+// SprintfT is Sprintf for args of any string kind
+func SprintfT[S ~string](format string, args ...S) string {
+	return Sprintf(format, Strings(args)...)
+}
+
+// ErrorfT is Errorf for args of any string kind
+func ErrorfT[S ~string](format string, args ...S) error {
+	return Errorf(format, Strings(args)...)
+}
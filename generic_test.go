@@ -0,0 +1,75 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"bytes"
+	"testing"
+)
+
+type (
+	testUserID string
+	testPath   string
+)
+
+// go test -count=1 -v -run "^TestGenericEntryPoints$"
+func TestGenericEntryPoints(t *testing.T) {
+
+	const (
+		format = "user %s opened %q"
+		want   = `user u42 opened "/tmp/x"`
+	)
+
+	if got := SprintfT(format, testUserID("u42"), "/tmp/x"); got != want {
+		t.Fatalf("SprintfT mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	// mixed string kinds
+	if got := Sprintf(format, AsString(testUserID("u42")), AsString(testPath("/tmp/x"))); got != want {
+		t.Fatalf("AsString mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	if got, want := SprintT(testPath("a"), testPath("b")), "ab"; got != want {
+		t.Fatalf("SprintT mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	if got, want := SprintlnT(testPath("a"), testPath("b")), "a b\n"; got != want {
+		t.Fatalf("SprintlnT mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	var buf bytes.Buffer
+
+	if _, err := FprintfT(&buf, format, testUserID("u42"), testUserID("/tmp/x")); err != nil || buf.String() != want {
+		t.Fatalf("FprintfT mismatch result: want <%s>, got <%s> (err: %v)", want, buf.String(), err)
+	}
+
+	if err := ErrorfT(format, testUserID("u42"), "/tmp/x"); err.Error() != want {
+		t.Fatalf("ErrorfT mismatch result: want <%s>, got <%s>", want, err)
+	}
+
+	ids := []testUserID{"a", "b"}
+
+	if ss := Strings(ids); (len(ss) != len(ids)) || (ss[0] != "a") || (ss[1] != "b") {
+		t.Fatalf("Strings mismatch result: %q", ss)
+	}
+
+	assertMallocs(t, "FprintfT", 0, func() {
+		_, _ = FprintfT(mallocWriter{}, "user %s opened %q", testUserID("u42"), testUserID("/tmp/x"))
+	})
+}
@@ -0,0 +1,183 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+// INFO: conditional groups
+//      Segment of printf-like format inside "%?(...)" is rendered only if all of the args it references directly
+//      (nested groups are checked separately) are non-empty, so optional parts of log templates vanish with their
+//      empty args: "request failed%?( (reason: %s))". The group ends at the closing paren, which balances its opening
+//      one, so raw text of the group may contain balanced parens, and unbalanced ones are escaped as "%(" and "%)".
+//      Groups are kept inside the flat tokens slice: group token (verbGroup) is followed by the tokens of the group
+//      and its width is the count of them. Raw text of groups isn't counted by xfmt.minSize, because groups vanish
+//      if all of the args are empty strings
+
+const (
+	charGroupMark  = '?'
+	charOpenParen  = '('
+	charCloseParen = ')'
+
+	// chars that end raw text of group
+	groupTextStops = "%()"
+
+	noGroupEndString = "%!(NOGROUPEND)"
+)
+
+var tokenErrNoGroupEnd = token{
+	verb:  verbNone,
+	value: noGroupEndString,
+}
+
+// openGroup is the state of group being parsed
+type openGroup struct {
+	start   int // index of group token
+	parens  int // depth of raw parens inside the group
+	minSize int // xfmt.minSize before the group
+}
+
+// close sets the count of the tokens of the group to its token
+// inlined
+//go:nosplit
+func (g *openGroup) close(tokens []token) {
+	tokens[g.start].width = int32(len(tokens) - g.start - 1)
+}
+
+// groupOf returns the tokens of the group, which token is tokens[i]
+// inlined
+//go:nosplit
+func groupOf(tokens []token, i int) []token {
+	return tokens[i+1 : i+1+int(tokens[i].width)]
+}
+
+// groupFilled reports whether all of the args referenced directly by the group (including indirect width and
+// precision args) are filled
+func groupFilled(group []token, filled func(arg uint32) bool) bool {
+
+	for i := 0; i < len(group); i++ {
+
+		token := &group[i]
+
+		switch token.verb {
+		case verbNone:
+			continue
+		case verbGroup:
+			// nested group is checked by itself
+			i += int(token.width)
+			continue
+		}
+
+		if token.flags.has(flagIndirectWidth) && !filled(uint32(token.width)) {
+			return false
+		}
+
+		if token.flags.has(flagIndirectPrec) && !filled(uint32(token.prec)) {
+			return false
+		}
+
+		if !filled(token.arg) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// skipGroup returns count of tokens to skip after the group token tokens[i], missing args are empty ones
+func skipGroup(tokens []token, i int, args []string) int {
+
+	filled := groupFilled(groupOf(tokens, i), func(arg uint32) bool {
+		return (uint(arg) < uint(len(args))) && (args[arg] != "")
+	})
+
+	if filled {
+		return 0
+	}
+
+	return int(tokens[i].width)
+}
+
+// skipGroupA is skipGroup for Arg values, values of non-string kinds (including errors and Stringers) are always
+// filled except for nil
+func skipGroupA(tokens []token, i int, args []Arg) int {
+
+	filled := groupFilled(groupOf(tokens, i), func(arg uint32) bool {
+		return (uint(arg) < uint(len(args))) && !args[arg].empty()
+	})
+
+	if filled {
+		return 0
+	}
+
+	return int(tokens[i].width)
+}
+
+// inlined
+//go:nosplit
+func (arg *Arg) empty() bool {
+	return (arg.kind == argNil) || ((arg.kind == argString) && (arg.s == ""))
+}
+
+// skipGroupNamed is skipGroup for named placeholders, positional args are always missing
+func (fmt *xfmt) skipGroupNamed(tokens []token, i int, args namedArgs) int {
+
+	filled := groupFilled(groupOf(tokens, i), func(arg uint32) bool {
+
+		if arg < namedArgBase {
+			return false
+		}
+
+		value, _ := args.lookup(fmt.names[arg-namedArgBase])
+
+		return value != ""
+	})
+
+	if filled {
+		return 0
+	}
+
+	return int(tokens[i].width)
+}
+
+// groupRefs returns args referenced directly by the group, SEE groupFilled()
+func groupRefs(group []token) (refs []uint32) {
+
+	groupFilled(group, func(arg uint32) bool {
+		refs = append(refs, arg)
+		return true
+	})
+
+	return refs
+}
+
+// compileGroup compiles the group into the step, which checks referenced args and then runs compiled tokens
+// SEE (*xfmt).compile()
+func compileGroup(group []token) fmtStep {
+
+	refs, chain := groupRefs(group), compileTokens(group)
+
+	return func(buf *buffer, args []string) {
+
+		for i := 0; i < len(refs); i++ {
+			if args[refs[i]] == "" {
+				return
+			}
+		}
+
+		chain.run(buf, args)
+	}
+}
@@ -0,0 +1,174 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// go test -count=1 -v -run "^TestConditionalGroups$"
+func TestConditionalGroups(t *testing.T) {
+
+	cases := [...]struct {
+		format string
+		args   []string
+		want   string
+	}{
+		{"request failed%?( (reason: %s))", []string{"timeout"}, "request failed (reason: timeout)"},
+		{"request failed%?( (reason: %s))", []string{""}, "request failed"},
+		{"request failed%?( (reason: %s))", nil, "request failed"},
+		{"%s%?(: %s)%?( [%s])", []string{"a", "b", "c"}, "a: b [c]"},
+		{"%s%?(: %s)%?( [%s])", []string{"a", "", "c"}, "a [c]"},
+		{"%s%?(: %s)%?( [%s])", []string{"a", "b", ""}, "a: b"},
+		{"%?(%s=%s)", []string{"k", ""}, ""},
+		{"%?(%s=%s)", []string{"k", "v"}, "k=v"},
+		{"%?(%[2]s)%[1]s", []string{"a", "b"}, "ba"},
+		{"%?(%[2]s)%[1]s", []string{"a", ""}, "a"},
+		{"%?(no args)", nil, "no args"},
+		{"%?()", nil, ""},
+
+		// nested groups are checked separately
+		{"%?(user %s%?( from %s))", []string{"bob", "host"}, "user bob from host"},
+		{"%?(user %s%?( from %s))", []string{"bob", ""}, "user bob"},
+		{"%?(user %s%?( from %s))", []string{"", "host"}, ""},
+
+		// parens and percents inside groups
+		{"%?((%s) (%s))", []string{"a", "b"}, "(a) (b)"},
+		{"%?( %( %s)", []string{"a"}, " ( a"},
+		{"%?( %) %s)", []string{"a"}, " ) a"},
+		{"%?(100%% %s)", []string{"a"}, "100% a"},
+		{"%?(%5s|%-5s)", []string{"a", "b"}, "    a|b    "},
+
+		// parens outside groups are raw text and "%(" is bad verb as before
+		{"(%s)", []string{"a"}, "(a)"},
+		{"%(", []string{"a"}, "%!((string=a)"},
+		{"%?", nil, "%!?(MISSING)"},
+
+		// errors
+		{"x%?( %s", []string{"a"}, "x a%!(NOGROUPEND)"},
+		{"x%?( %s", []string{""}, "x%!(NOGROUPEND)"},
+		{"%?(%s)", []string{"a", "b"}, "a%!(EXTRA string=b)"},
+		{"%?(%*s)", []string{"a", "b"}, "%!(BADWIDTH)b"},
+		{"%?(%*s)", []string{"", "b"}, ""},
+	}
+
+	defer SetCacheThreshold(CacheThreshold())
+
+	for _, threshold := range [...]uint{CacheAlways, CacheRepetitions, CacheDisabled} {
+
+		purgeCaches()
+
+		SetCacheThreshold(threshold)
+
+		for i := 0; i < 3; i++ {
+			for _, c := range cases {
+
+				if got := Sprintf(c.format, c.args...); got != c.want {
+					t.Errorf("%d: %q %q: mismatch result: want <%s>, got <%s>", threshold, c.format, c.args, c.want, got)
+				}
+
+				var buf bytes.Buffer
+
+				xfmt := forgeXfmt(c.format)
+
+				if _, err := xfmt.Fstream(&buf, c.args); (err != nil) || (buf.String() != c.want) {
+					t.Errorf("%d: %q %q: Fstream mismatch result: want <%s>, got <%s> (err: %v)", threshold, c.format,
+						c.args, c.want, buf.String(), err)
+				}
+			}
+		}
+
+		if threshold == CacheDisabled {
+			continue
+		}
+
+		args := []string{"failed", ""}
+
+		assertMallocs(t, "Fprintf with skipped group", 0, func() {
+			_, _ = Fprintf(mallocWriter{}, "request %s%?( (reason: %s))", args...)
+		})
+	}
+}
+
+// go test -count=1 -v -run "^TestConditionalGroupsMinSize$"
+func TestConditionalGroupsMinSize(t *testing.T) {
+
+	cases := [...]struct {
+		format string
+		want   int
+	}{
+		{"request failed%?( (reason: %s))", len("request failed")},
+		{"a%?(b%?(c%s)d%s)e", len("ae")},
+		{"a%?(b%%)e", len("ae")},
+		{"a%?(b", len("a") + len(noGroupEndString)},
+		{"a%?(b%?(c", len("a") + len(noGroupEndString)},
+	}
+
+	for _, c := range cases {
+		if got := parseFormat(c.format).minSize; got != c.want {
+			t.Errorf("%q: mismatch min size: want %d, got %d", c.format, c.want, got)
+		}
+	}
+}
+
+// go test -count=1 -v -run "^TestConditionalGroupsArgs$"
+func TestConditionalGroupsArgs(t *testing.T) {
+
+	if got, want := SprintfA("%d%?( (%s))%?( err=%v)", Int(7), Str(""), Err(nil)), "7"; got != want {
+		t.Errorf("SprintfA mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	if got, want := SprintfA("%d%?( (%s))%?( n=%d)", Int(7), Bytes([]byte("x")), Int(0)), "7 (x) n=0"; got != want {
+		t.Errorf("SprintfA mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	args := map[string]string{"user": "bob", "reason": ""}
+
+	if got, want := SprintfMap("%{user}s%?( (%{reason}s))%?( %{host}s)", args), "bob"; got != want {
+		t.Errorf("SprintfMap mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	args["reason"] = "timeout"
+
+	if got, want := SprintfMap("%{user}s%?( (%{reason}s))", args), "bob (timeout)"; got != want {
+		t.Errorf("SprintfMap mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	if n, err := Sscanf("a (b)", "%s%?( (%s))", new(string), new(string)); (n != 1) || (err != ErrScanVerb) {
+		t.Errorf("Sscanf mismatch result: want 1 %v, got %d %v", ErrScanVerb, n, err)
+	}
+
+	if got := CompareSignatures("failed%?( (reason: %s))", "ошибка%?( (причина: %s))"); got != nil {
+		t.Errorf("CompareSignatures mismatch result: %v", got)
+	}
+
+	var buf bytes.Buffer
+
+	long := strings.Repeat("x", vecThreshold)
+
+	if _, _ = FprintfVec(&buf, "%s%?( (%s))%?( [%s])", long, "", "y"); buf.String() != long+" [y]" {
+		t.Errorf("FprintfVec mismatch result")
+	}
+
+	if s := strings.Repeat("%?(%s)", 3); Sprintf(s, "a", "", "c") != "ac" {
+		t.Errorf("Sprintf mismatch result: %s", Sprintf(s, "a", "", "c"))
+	}
+}
@@ -0,0 +1,171 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import "encoding/binary"
+
+// hexPairs is a table of ready-made two-digit hex encodings of every byte value (256 * 2 = 512 bytes)
+// Every pair is stored as little-endian uint16 (high digit first in memory), so both digits of a byte are
+// written with a single store, and several pairs are combined into one machine word
+type hexPairs [256]uint16
+
+var (
+	lhexPairs = makeHexPairs(ldigits)
+	uhexPairs = makeHexPairs(udigits)
+)
+
+func makeHexPairs(digits string) (t hexPairs) {
+
+	for c := range t {
+		t[c] = uint16(digits[c>>4]) | uint16(digits[c&0x0F])<<8
+	}
+
+	return t
+}
+
+const (
+	// every element of spaced form occupies "hh " (3 bytes), 8 elements exactly fill 3 machine words
+	hexSpacedElemSize = 3
+	// every element of alt spaced form occupies "0xhh " (5 bytes), 8 elements exactly fill 5 machine words
+	hexAltSpacedElemSize = 5
+
+	// amount of src bytes processed per one iteration of the word-at-a-time loops
+	hexWordStep = 8
+)
+
+// hexEncode writes plain hex encoding of `s` into `dst`, which len must be at least 2*len(s)
+// NOTE every 8 src bytes are loaded as one word and written as two 16-byte words
+//go:nosplit
+func hexEncode(dst []byte, s string, t *hexPairs) {
+
+	src := stringBytes(s)
+
+	j := uint(0)
+
+	for ; len(src) >= hexWordStep; src = src[hexWordStep:] {
+
+		v := binary.LittleEndian.Uint64(src)
+
+		binary.LittleEndian.PutUint64(dst[j:], uint64(t[byte(v)])|uint64(t[byte(v>>8)])<<16|
+			uint64(t[byte(v>>16)])<<32|uint64(t[byte(v>>24)])<<48)
+
+		binary.LittleEndian.PutUint64(dst[j+8:], uint64(t[byte(v>>32)])|uint64(t[byte(v>>40)])<<16|
+			uint64(t[byte(v>>48)])<<32|uint64(t[v>>56])<<48)
+
+		j += 2 * hexWordStep
+	}
+
+	// tail
+	for _, c := range src {
+		binary.LittleEndian.PutUint16(dst[j:], t[c])
+		j += 2
+	}
+}
+
+// hexEncodeSpaced writes space separated hex encoding of `s` ("hh hh hh") into `dst`, which len must be at
+// least 3*len(s)-1
+// NOTE every element except the last one is written together with its trailing separator
+//go:nosplit
+func hexEncodeSpaced(dst []byte, s string, t *hexPairs) {
+
+	src := stringBytes(s)
+
+	// the last element has no trailing separator, so is written separately
+	last := src[len(src)-1]
+	src = src[:len(src)-1]
+
+	j := uint(0)
+
+	for ; len(src) >= hexWordStep; src = src[hexWordStep:] {
+
+		v := binary.LittleEndian.Uint64(src)
+
+		const sep = charSpace << 16
+
+		e0, e1 := uint64(t[byte(v)])|sep, uint64(t[byte(v>>8)])|sep
+		e2, e3 := uint64(t[byte(v>>16)])|sep, uint64(t[byte(v>>24)])|sep
+		e4, e5 := uint64(t[byte(v>>32)])|sep, uint64(t[byte(v>>40)])|sep
+		e6, e7 := uint64(t[byte(v>>48)])|sep, uint64(t[v>>56])|sep
+
+		// 8 elements * 24 bits = 3 words
+		binary.LittleEndian.PutUint64(dst[j:], e0|e1<<24|e2<<48)
+		binary.LittleEndian.PutUint64(dst[j+8:], e2>>16|e3<<8|e4<<32|e5<<56)
+		binary.LittleEndian.PutUint64(dst[j+16:], e5>>8|e6<<16|e7<<40)
+
+		j += hexSpacedElemSize * hexWordStep
+	}
+
+	// tail
+	for _, c := range src {
+		binary.LittleEndian.PutUint16(dst[j:], t[c])
+		dst[j+2] = charSpace
+		j += hexSpacedElemSize
+	}
+
+	binary.LittleEndian.PutUint16(dst[j:], t[last])
+}
+
+// hexEncodeAltSpaced writes space separated hex encoding of `s` with leading 0x or 0X for each element
+// ("0xhh 0xhh 0xhh") into `dst`, which len must be at least 5*len(s)-1
+// SEE hexEncodeSpaced()
+//go:nosplit
+func hexEncodeAltSpaced(dst []byte, s string, t *hexPairs, x byte) {
+
+	src := stringBytes(s)
+
+	prefix := uint16(charZero) | uint16(x)<<8
+
+	// prefix and separator of every element, digits are placed between them
+	sep := uint64(prefix) | charSpace<<32
+
+	last := src[len(src)-1]
+	src = src[:len(src)-1]
+
+	j := uint(0)
+
+	for ; len(src) >= hexWordStep; src = src[hexWordStep:] {
+
+		v := binary.LittleEndian.Uint64(src)
+
+		e0, e1 := sep|uint64(t[byte(v)])<<16, sep|uint64(t[byte(v>>8)])<<16
+		e2, e3 := sep|uint64(t[byte(v>>16)])<<16, sep|uint64(t[byte(v>>24)])<<16
+		e4, e5 := sep|uint64(t[byte(v>>32)])<<16, sep|uint64(t[byte(v>>40)])<<16
+		e6, e7 := sep|uint64(t[byte(v>>48)])<<16, sep|uint64(t[v>>56])<<16
+
+		// 8 elements * 40 bits = 5 words
+		binary.LittleEndian.PutUint64(dst[j:], e0|e1<<40)
+		binary.LittleEndian.PutUint64(dst[j+8:], e1>>24|e2<<16|e3<<56)
+		binary.LittleEndian.PutUint64(dst[j+16:], e3>>8|e4<<32)
+		binary.LittleEndian.PutUint64(dst[j+24:], e4>>32|e5<<8|e6<<48)
+		binary.LittleEndian.PutUint64(dst[j+32:], e6>>16|e7<<24)
+
+		j += hexAltSpacedElemSize * hexWordStep
+	}
+
+	// tail
+	for _, c := range src {
+		binary.LittleEndian.PutUint16(dst[j:], prefix)
+		binary.LittleEndian.PutUint16(dst[j+2:], t[c])
+		dst[j+4] = charSpace
+		j += hexAltSpacedElemSize
+	}
+
+	binary.LittleEndian.PutUint16(dst[j:], prefix)
+	binary.LittleEndian.PutUint16(dst[j+2:], t[last])
+}
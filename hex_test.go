@@ -0,0 +1,121 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+// go test -count=1 -v -run "^TestHexEncoders$"
+func TestHexEncoders(t *testing.T) {
+
+	formats := [...]string{
+		"%x", "%X", "% x", "% X", "%#x", "%#X", "% #x", "% #X",
+		"%-#40x", "%# 30X", "%.5x", "% .9x", "%# .17X", "%0#20x",
+	}
+
+	// all possible byte values and lengths around word boundaries
+	var src [3*hexWordStep + 256]byte
+
+	for i := range src {
+		src[i] = byte(i*7 + i>>8)
+	}
+
+	for _, format := range formats {
+
+		x := parseFormat(format)
+
+		for sz := 0; sz <= len(src); sz++ {
+
+			s := string(src[:sz])
+
+			if want, got := fmt.Sprintf(format, s), x.Sprint([]string{s}); got != want {
+				t.Fatalf("%q (len %d): mismatch result: want <%s>, got <%s>", format, sz, want, got)
+			}
+		}
+	}
+}
+
+// go test -count=1 -run "^$" -bench "^BenchmarkHexEncode$" -benchmem
+func BenchmarkHexEncode(b *testing.B) {
+
+	sizes := [...]struct {
+		name string
+		sz   int
+	}{
+		{"16B", 16},
+		{"1KiB", 1 << 10},
+		{"64KiB", 64 << 10},
+	}
+
+	variants := [...]struct {
+		name  string
+		flags flags
+	}{
+		{"plain", 0},
+		{"spaced", flagWithSpace},
+		{"alt", flagAltFmt},
+		{"altSpaced", flagWithSpace | flagAltFmt},
+	}
+
+	for _, size := range sizes {
+
+		src := make([]byte, size.sz)
+
+		for i := range src {
+			src[i] = byte(i * 13)
+		}
+
+		s := string(src)
+
+		for _, v := range variants {
+
+			flags := v.flags
+
+			b.Run(size.name+"/"+v.name, func(b *testing.B) {
+
+				var buf buffer
+
+				buf.init(nil)
+
+				b.SetBytes(int64(len(s)))
+				b.ResetTimer()
+
+				for i := 0; i < b.N; i++ {
+					buf.buf = buf.buf[:0]
+					fmtHex(&buf, s, flags, absentValue, absentValue)
+				}
+			})
+		}
+
+		b.Run(size.name+"/encoding-hex", func(b *testing.B) {
+
+			dst := make([]byte, hex.EncodedLen(len(src)))
+
+			b.SetBytes(int64(len(src)))
+			b.ResetTimer()
+
+			for i := 0; i < b.N; i++ {
+				hex.Encode(dst, src)
+			}
+		})
+	}
+}
@@ -0,0 +1,221 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+// INFO: included formats
+//      Common fragments of printf-like formats (request prefix, tenant tag) are registered once by RegisterFormat and
+//      included by "%{>name}" directive, which splices parsed tokens of the fragment into the format. Positional args
+//      of the fragment are remapped to follow the args consumed before the directive ("%s %{>prefix} %s" with
+//      prefix "[%s|%s]" uses args 1 and 2 for the prefix and arg 3 for the last verb), names of named placeholders
+//      are shared with the format. Fragments may include other fragments; the registry is rebuilt on every
+//      registration, so all of the fragments are parsed once per registration and forward references are resolved
+//      by later registrations, and cyclic includes are rejected. Registration resets the format cache, so cached
+//      formats never use old fragments (formats parsed concurrently with the registration aren't cached). Formats of
+//      catalogs are compiled when messages are added, so their includes are resolved at that time: fragments should
+//      be registered before messages are added to catalogs, messages added again are recompiled
+
+const (
+	charInclude = '>'
+
+	unknownFormatString = "(NOFORMAT "
+	includeCycleString  = "(CYCLE "
+)
+
+// includer resolves included formats while parsing
+type includer interface {
+	// include returns parsed format by name or nil and error mark
+	include(name string) (fmt *xfmt, mark string)
+}
+
+// fragments is immutable snapshot of registered formats
+type fragments map[string]*xfmt
+
+// inlined
+//go:nosplit
+func (frags fragments) include(name string) (fmt *xfmt, mark string) {
+
+	if fmt = frags[name]; fmt == nil {
+		return nil, unknownFormatString
+	}
+
+	return fmt, ""
+}
+
+// fragmentsBuilder parses registered formats resolving their includes and detecting cycles
+type fragmentsBuilder struct {
+	sources  map[string]string
+	built    fragments
+	visiting []string // stack of formats being parsed
+	cycle    bool
+}
+
+func (b *fragmentsBuilder) include(name string) (fmt *xfmt, mark string) {
+
+	if fmt = b.built[name]; fmt != nil {
+		return fmt, ""
+	}
+
+	source, ok := b.sources[name]
+
+	if !ok {
+		return nil, unknownFormatString
+	}
+
+	for i := 0; i < len(b.visiting); i++ {
+		if b.visiting[i] == name {
+			b.cycle = true
+			return nil, includeCycleString
+		}
+	}
+
+	b.visiting = append(b.visiting, name)
+
+	xfmt := parseFormatWith(source, b)
+	xfmt.shrink()
+
+	b.visiting = b.visiting[:len(b.visiting)-1]
+
+	b.built[name] = &xfmt
+
+	return &xfmt, ""
+}
+
+var formatRegistry struct {
+	lock    sync.Mutex
+	sources map[string]string // guarded by lock
+	frags   unsafe.Pointer    // fragments
+}
+
+// loadFragments returns snapshot of registered formats
+// inlined
+//go:nosplit
+func loadFragments() fragments {
+	ptr := atomic.LoadPointer(&formatRegistry.frags)
+	return *(*fragments)(unsafe.Pointer(&ptr))
+}
+
+// IncludeError is the error of format registration
+type IncludeError struct {
+	Name   string
+	Reason string
+}
+
+func (e *IncludeError) Error() string {
+	return "xfmt: can't register format " + strconv.Quote(e.Name) + ": " + e.Reason
+}
+
+// reasons of IncludeError
+const (
+	includeReasonName  = "bad name"
+	includeReasonCycle = "include cycle"
+)
+
+// RegisterFormat registers printf-like format under name for "%{>name}" includes replacing existing one, format
+// may include other registered formats (and formats registered later), but cyclic includes are rejected
+// NOTE already added messages of catalogs keep their includes (SEE Catalog)
+// thread-safe
+func RegisterFormat(name, format string) error {
+
+	if (name == "") || (strings.IndexByte(name, charCloseName) != -1) {
+		return &IncludeError{Name: name, Reason: includeReasonName}
+	}
+
+	formatRegistry.lock.Lock()
+	defer formatRegistry.lock.Unlock()
+
+	sources := make(map[string]string, len(formatRegistry.sources)+1)
+
+	for n, s := range formatRegistry.sources {
+		sources[n] = s
+	}
+
+	sources[name] = format
+
+	b := fragmentsBuilder{
+		sources: sources,
+		built:   make(fragments, len(sources)),
+	}
+
+	// the registry hasn't any cycles before, so any cycle is caused by the new format
+	for n := range sources {
+
+		b.include(n)
+
+		if b.cycle {
+			return &IncludeError{Name: name, Reason: includeReasonCycle}
+		}
+	}
+
+	formatRegistry.sources = sources
+
+	atomic.StorePointer(&formatRegistry.frags, *(*unsafe.Pointer)(unsafe.Pointer(&b.built)))
+
+	// cached formats may include either old version of the format or miss it
+	xfmtCache.Reset()
+
+	return nil
+}
+
+// includeFormat appends tokens of the included format to tokens remapping its positional args by `offset` and its
+// named placeholders to names
+func includeFormat(tokens []token, names []string, fmt *xfmt, offset int) ([]token, []string) {
+
+	for i := 0; i < len(fmt.tokens); i++ {
+
+		token := fmt.tokens[i]
+
+		if (token.verb != verbNone) && (token.verb != verbGroup) {
+
+			if token.arg >= namedArgBase {
+				var idx int
+				idx, names = nameIndex(names, fmt.names[token.arg-namedArgBase])
+				token.arg = namedArgBase + uint32(idx)
+			} else {
+				token.arg += uint32(offset)
+			}
+
+			if token.flags.has(flagIndirectWidth) {
+				token.width += int32(offset)
+			}
+
+			if token.flags.has(flagIndirectPrec) {
+				token.prec += int32(offset)
+			}
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	return tokens, names
+}
+
+// SEE badArgNumValue()
+// inlined
+//go:nosplit
+func badIncludeValue(mark, name string) string {
+	return percentBangString + mark + name + rightParensStr
+}
@@ -0,0 +1,180 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"errors"
+	"testing"
+)
+
+// resetFormatRegistry removes all of the registered formats
+func resetFormatRegistry() {
+	formatRegistry.sources = nil
+	formatRegistry.frags = nil
+	xfmtCache.Reset()
+}
+
+// go test -count=1 -v -run "^TestIncludeFormat$"
+func TestIncludeFormat(t *testing.T) {
+
+	defer resetFormatRegistry()
+
+	for name, format := range map[string]string{
+		"reqprefix": "[%s %-4s]",
+		"tenant":    "%{>reqprefix} tenant=%q",
+		"user":      "user=%{user}s",
+		"reason":    "%?( (reason: %s))",
+		"width":     "%*s|",
+		"plain":     "plain",
+		"later":     "%{>notyet}",
+	} {
+		if err := RegisterFormat(name, format); err != nil {
+			t.Fatalf("RegisterFormat %q error: %v", name, err)
+		}
+	}
+
+	cases := [...]struct {
+		format string
+		args   []string
+		want   string
+	}{
+		{"%{>reqprefix} done in %s", []string{"r1", "GET", "5ms"}, "[r1 GET ] done in 5ms"},
+		{"%s: %{>reqprefix} %s", []string{"app", "r1", "GET", "5ms"}, "app: [r1 GET ] 5ms"},
+		{"%{>tenant}: %s", []string{"r1", "GET", "acme", "ok"}, `[r1 GET ] tenant="acme": ok`},
+		{"%{>reqprefix}%{>reqprefix}", []string{"a", "b", "c", "d"}, "[a b   ][c d   ]"},
+		{"%[2]s %{>plain} %s", []string{"a", "b", "c"}, "b plain c"},
+		{"failed%{>reason}", []string{""}, "failed"},
+		{"failed%{>reason}", []string{"timeout"}, "failed (reason: timeout)"},
+		{"%{>reqprefix}", []string{"a"}, "[a %!s(MISSING)]"},
+		{"%s %{>width}", []string{"a", "b", "c"}, "a %!(BADWIDTH)c|"},
+
+		// errors
+		{"%{>unknown} %s", []string{"a"}, "%!(NOFORMAT unknown) a"},
+		{"%{>later}", nil, "%!(NOFORMAT notyet)"},
+		{"x %{>reqprefix", []string{"a"}, "x %!(NOVERB)%!(EXTRA string=a)"},
+		// include can't have flags, so it's named placeholder without verb
+		{"%-{>plain}", nil, "%!(NOVERB)"},
+	}
+
+	for _, c := range cases {
+		if got := Sprintf(c.format, c.args...); got != c.want {
+			t.Errorf("%q %q: mismatch result: want <%s>, got <%s>", c.format, c.args, c.want, got)
+		}
+	}
+
+	if got, want := SprintfMap("%{>user} %{>user}@%{host}s", map[string]string{"user": "bob", "host": "h"}),
+		"user=bob user=bob@h"; got != want {
+		t.Errorf("SprintfMap mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	if got, want := parseFormat("%{>reqprefix} done in %s").minSize, len("[ ] done in "); got != want {
+		t.Errorf("mismatch min size: want %d, got %d", want, got)
+	}
+
+	// forward reference is resolved by later registration, cached formats are reset
+	if err := RegisterFormat("notyet", "resolved %s"); err != nil {
+		t.Fatalf("RegisterFormat error: %v", err)
+	}
+
+	if got, want := Sprintf("%{>later}", "now"), "resolved now"; got != want {
+		t.Errorf("mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	// replaced format is used by formats including it
+	if err := RegisterFormat("reqprefix", "<%s>"); err != nil {
+		t.Fatalf("RegisterFormat error: %v", err)
+	}
+
+	if got, want := Sprintf("%{>tenant}: %s", "r1", "acme", "ok"), `<r1> tenant="acme": ok`; got != want {
+		t.Errorf("mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	// format parsed with old fragments concurrently with registration must not be cached
+	key := formatKey{format: "%{>reqprefix}"}
+
+	version := xfmtCache.Version()
+
+	old := parseFormat(key.format)
+
+	if err := RegisterFormat("reqprefix", "(%s)"); err != nil {
+		t.Fatalf("RegisterFormat error: %v", err)
+	}
+
+	xfmtCache.Set(key, old, version)
+
+	if _, has := xfmtCache.Get(key); has {
+		t.Error("outdated format is cached")
+	}
+
+	if got, want := Sprintf(key.format, "r1"), "(r1)"; got != want {
+		t.Errorf("mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	assertMallocs(t, "Fprintf with include", 0, func() {
+		_, _ = Fprintf(mallocWriter{}, "%{>tenant}: %s", "r1", "acme", "ok")
+	})
+}
+
+// go test -count=1 -v -run "^TestIncludeFormatErrors$"
+func TestIncludeFormatErrors(t *testing.T) {
+
+	defer resetFormatRegistry()
+
+	cases := [...]struct {
+		name, format string
+		reason       string
+	}{
+		{"a", "a=%s", ""},
+		{"b", "%{>a} b=%s", ""},
+		{"c", "%{>c}", includeReasonCycle},
+		{"a", "%{>b}", includeReasonCycle},
+		{"d", "%{>e}", ""},
+		{"e", "%?(%{>d})", includeReasonCycle},
+		{"", "x", includeReasonName},
+		{"x}", "x", includeReasonName},
+	}
+
+	for _, c := range cases {
+
+		err := RegisterFormat(c.name, c.format)
+
+		if c.reason == "" {
+			if err != nil {
+				t.Errorf("%q: unexpected error: %v", c.name, err)
+			}
+
+			continue
+		}
+
+		var ierr *IncludeError
+
+		if !errors.As(err, &ierr) || (ierr.Name != c.name) || (ierr.Reason != c.reason) {
+			t.Errorf("%q: mismatch error: want %s, got %v", c.name, c.reason, err)
+		}
+	}
+
+	// rejected formats aren't registered
+	if got, want := Sprintf("%{>b}", "1", "2"), "a=1 b=2"; got != want {
+		t.Errorf("mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	if got, want := Sprintf("%{>c}"), "%!(NOFORMAT c)"; got != want {
+		t.Errorf("mismatch result: want <%s>, got <%s>", want, got)
+	}
+}
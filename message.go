@@ -0,0 +1,720 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import "strings"
+
+// INFO: message formats
+//      ICU MessageFormat subset "{count, plural, =0 {no files} one {# file} other {# files}}" is parsed by separate
+//      frontend into tokens tree: simple argument "{0}" or "{name}" is the `%v` token, plural and select
+//      arguments are the clause tokens (verbClause), which refer to xfmt.clauses, and cases of clauses are nested
+//      tokens slices. The argument is either 0-based arg index or name (SEE named.go). Selector of plural is the
+//      arg string parsed as a decimal number, it's matched against explicit values ("=1") first and then against
+//      CLDR plural category of the language; "#" inside plural case is the selector itself. Select clause matches
+//      the arg string against its keywords. Both clauses fall back to the required "other" case. Like in ICU, "''"
+//      is an apostrophe and apostrophe before "{", "}", "#" and "|" starts quoted literal text up to the next
+//      single apostrophe. Plural rules aren't a part of the parsed format, they are picked by the language at
+//      every call, so cached formats are shared by all of the languages
+
+const (
+	charApostrophe  = '\''
+	charPluralValue = '#'
+	charPipe        = '|'
+	charExactValue  = '='
+
+	messagePlural = "plural"
+	messageSelect = "select"
+	messageOther  = "other"
+
+	// chars that end raw text of message
+	messageSpecialChars = "'{}#"
+)
+
+// clause is plural or select argument of message format
+type clause struct {
+	plural bool
+	keys   []string  // selectors of cases: plural categories, explicit values "=n" or select keywords
+	cases  [][]token // parallel to keys
+	other  int       // index of required "other" case
+}
+
+var messageFrontend = frontend{
+	syntax: syntaxMessage,
+	parse:  parseMessageFormat,
+}
+
+// messageParser is the state of recursive descent parser of message format
+type messageParser struct {
+	format   string
+	pos      int
+	needArgs int
+	minSize  int
+	names    []string
+	clauses  []clause
+}
+
+// NOTE retval by value
+func parseMessageFormat(format string) xfmt {
+
+	p := messageParser{format: format}
+
+	tokens := p.parseMessage(0, false, false)
+
+	return xfmt{
+		tokens:  tokens,
+		args:    uint(p.needArgs),
+		minSize: p.minSize,
+		names:   p.names,
+		clauses: p.clauses,
+	}
+}
+
+// parseMessage parses message text up to the end of format or, if it's `nested` case of clause, up to the closing
+// brace, which isn't consumed; `#` is the value of plural arg `pluralArg` only `inPlural` case
+func (p *messageParser) parseMessage(pluralArg uint32, inPlural, nested bool) (tokens []token) {
+
+	var lit literal
+
+	for format := p.format; p.pos < len(format); {
+
+		switch c := format[p.pos]; {
+		case c == charApostrophe:
+			p.pos++
+			tokens = p.parseQuoted(tokens, &lit, inPlural)
+			continue
+		case c == charOpenName:
+			tokens = append(tokens, p.parseArgument(pluralArg, inPlural))
+			lit.open = false
+			continue
+		case (c == charCloseName) && nested:
+			return tokens
+		case (c == charPluralValue) && inPlural:
+			tokens = append(tokens, token{
+				verb:  verbValue,
+				value: verbValueString,
+				width: absentValue,
+				prec:  absentValue,
+				arg:   pluralArg,
+			})
+			lit.open = false
+			p.pos++
+			continue
+		}
+
+		// raw text up to the next special char, the current char is always raw text here
+		end := p.pos + 1
+
+		if i := strings.IndexAny(format[end:], messageSpecialChars); i == -1 {
+			end = len(format)
+		} else {
+			end += i
+		}
+
+		tokens = p.appendLiteral(tokens, &lit, p.pos, end)
+	}
+
+	return tokens
+}
+
+// inlined
+//go:nosplit
+func (p *messageParser) appendLiteral(tokens []token, lit *literal, start, end int) []token {
+	p.minSize += end - start
+	p.pos = end
+	return appendLiteral(tokens, lit, p.format, start, end)
+}
+
+// parseQuoted parses text after apostrophe: "''" is an apostrophe, apostrophe before special char starts quoted
+// literal text, otherwise apostrophe is just a char
+func (p *messageParser) parseQuoted(tokens []token, lit *literal, inPlural bool) []token {
+
+	format := p.format
+
+	if p.pos >= len(format) {
+		return p.appendLiteral(tokens, lit, p.pos-1, p.pos)
+	}
+
+	switch c := format[p.pos]; {
+	case c == charApostrophe:
+		return p.appendLiteral(tokens, lit, p.pos, p.pos+1)
+	case (c == charOpenName) || (c == charCloseName) || (c == charPipe) || ((c == charPluralValue) && inPlural):
+	default:
+		p.pos--
+		return p.appendLiteral(tokens, lit, p.pos, p.pos+1)
+	}
+
+	for p.pos < len(format) {
+
+		i := strings.IndexByte(format[p.pos:], charApostrophe)
+
+		// unfinished quoted text lasts up to the end
+		if i == -1 {
+			return p.appendLiteral(tokens, lit, p.pos, len(format))
+		}
+
+		tokens = p.appendLiteral(tokens, lit, p.pos, p.pos+i)
+
+		// skip apostrophe
+		p.pos++
+
+		// "''" inside quoted text is an apostrophe
+		if (p.pos < len(format)) && (format[p.pos] == charApostrophe) {
+			tokens = p.appendLiteral(tokens, lit, p.pos, p.pos+1)
+			continue
+		}
+
+		break
+	}
+
+	return tokens
+}
+
+// parseArgument parses argument "{arg}", "{arg, plural, ...}" or "{arg, select, ...}" starting at the open brace,
+// malformed argument is parsed as BADSPEC error and unfinished one as NOVERB error, which is the last token
+func (p *messageParser) parseArgument(pluralArg uint32, inPlural bool) token {
+
+	format, start := p.format, p.pos
+
+	p.pos++
+
+	id := p.word()
+
+	if id == "" {
+		return p.badArgument(start)
+	}
+
+	var arg uint32
+
+	if ('0' <= id[0]) && (id[0] <= '9') {
+
+		n, j := pickNumValue(id, 0)
+
+		if (n == absentValue) || (j != uint(len(id))) {
+			return p.badArgument(start)
+		}
+
+		if n >= p.needArgs {
+			p.needArgs = n + 1
+		}
+
+		arg = uint32(n)
+	} else {
+
+		var idx int
+
+		idx, p.names = nameIndex(p.names, id)
+
+		arg = namedArgBase + uint32(idx)
+	}
+
+	tok := token{
+		verb:  verbValue,
+		value: verbValueString,
+		width: absentValue,
+		prec:  absentValue,
+		arg:   arg,
+	}
+
+	// simple argument
+	if p.skip(charCloseName) {
+		return tok
+	}
+
+	if !p.skip(charAlignment) {
+		return p.badArgument(start)
+	}
+
+	c := clause{other: absentValue}
+
+	switch p.word() {
+	case messagePlural:
+		c.plural, pluralArg, inPlural = true, arg, true
+	case messageSelect:
+	default:
+		return p.badArgument(start)
+	}
+
+	if !p.skip(charAlignment) {
+		return p.badArgument(start)
+	}
+
+	for !p.skip(charCloseName) {
+
+		key := p.word()
+
+		if (key == "") || !c.validKey(key) || !p.skip(charOpenName) {
+			return p.badArgument(start)
+		}
+
+		tokens := p.parseMessage(pluralArg, inPlural, true)
+
+		// here is either the closing brace of the case or the end of format
+		if p.pos >= len(format) {
+			return tokenErrNoVerb
+		}
+
+		p.pos++
+
+		if key == messageOther {
+			c.other = len(c.keys)
+		}
+
+		c.keys = append(c.keys, key)
+		c.cases = append(c.cases, tokens)
+	}
+
+	if c.other == absentValue {
+		return p.badArgument(start)
+	}
+
+	tok.verb, tok.width = verbClause, int32(len(p.clauses))
+
+	p.clauses = append(p.clauses, c)
+
+	return tok
+}
+
+// validKey reports whether key is valid selector of the clause
+//go:nosplit
+func (c *clause) validKey(key string) bool {
+
+	if !c.plural {
+		return true
+	}
+
+	if key[0] == charExactValue {
+		_, ok := parsePluralOperands(key[1:])
+		return ok
+	}
+
+	switch key {
+	case pluralZero, pluralOne, pluralTwo, pluralFew, pluralMany, messageOther:
+		return true
+	}
+
+	return false
+}
+
+// badArgument skips malformed argument starting at `start` up to its matching closing brace and returns BADSPEC
+// error token or NOVERB one if the argument is unfinished
+func (p *messageParser) badArgument(start int) token {
+
+	depth := 0
+
+	for i := start; i < len(p.format); i++ {
+
+		switch p.format[i] {
+		case charOpenName:
+			depth++
+		case charCloseName:
+			if depth--; depth == 0 {
+				p.pos = i + 1
+				p.minSize += len(badSpecString)
+				return tokenErrBadSpec
+			}
+		}
+	}
+
+	p.pos = len(p.format)
+
+	return tokenErrNoVerb
+}
+
+// word skips white spaces and returns the next word up to white space or syntax char, then skips white spaces
+func (p *messageParser) word() string {
+
+	p.skipSpaces()
+
+	start := p.pos
+
+	for ; p.pos < len(p.format); p.pos++ {
+		if c := p.format[p.pos]; isMessageSpace(c) || (c == charAlignment) || (c == charOpenName) ||
+			(c == charCloseName) || (c == charApostrophe) {
+			break
+		}
+	}
+
+	word := p.format[start:p.pos]
+
+	p.skipSpaces()
+
+	return word
+}
+
+// skip skips white spaces and then c if it is the next char
+//go:nosplit
+func (p *messageParser) skip(c byte) bool {
+
+	p.skipSpaces()
+
+	if (p.pos < len(p.format)) && (p.format[p.pos] == c) {
+		p.pos++
+		return true
+	}
+
+	return false
+}
+
+// inlined
+//go:nosplit
+func (p *messageParser) skipSpaces() {
+	for (p.pos < len(p.format)) && isMessageSpace(p.format[p.pos]) {
+		p.pos++
+	}
+}
+
+// inlined
+//go:nosplit
+func isMessageSpace(c byte) bool {
+	return (c == charSpace) || (c == '\t') || (c == '\n') || (c == '\r')
+}
+
+// messageArgs is the source of args of message format: positional args and named ones
+type messageArgs struct {
+	args  []string
+	named namedArgs // may be nil
+}
+
+// inlined
+//go:nosplit
+func (fmt *xfmt) messageArg(idx uint32, args *messageArgs) (value string, ok bool) {
+
+	if idx < namedArgBase {
+
+		if idx >= uint32(len(args.args)) {
+			return "", false
+		}
+
+		return args.args[idx], true
+	}
+
+	if args.named == nil {
+		return "", false
+	}
+
+	return args.named.lookup(fmt.names[idx-namedArgBase])
+}
+
+// SEE bprintTo()
+func (fmt *xfmt) bprintMessageTo(buf *buffer, tokens []token, args *messageArgs, rule pluralRule) {
+
+	// arg of the current argument
+	var arg [1]string
+
+	for i := 0; i < len(tokens); i++ {
+
+		token := &tokens[i]
+
+		if token.verb == verbNone {
+			buf.WriteString(token.value)
+			continue
+		}
+
+		value, ok := fmt.messageArg(token.arg, args)
+
+		switch {
+		case !ok && (token.arg >= namedArgBase):
+			token.missingName(buf, fmt.names[token.arg-namedArgBase])
+		case !ok:
+			token.missingArg(buf)
+		case token.verb == verbClause:
+			c := &fmt.clauses[token.width]
+			fmt.bprintMessageTo(buf, c.cases[c.choose(value, rule)], args, rule)
+		default:
+			// argument is formatted as positional verb with single arg
+			tok := *token
+			tok.arg, arg[0] = 0, value
+
+			tok.format(buf, arg[:])
+		}
+	}
+}
+
+// choose returns index of the case matched by value
+func (c *clause) choose(value string, rule pluralRule) int {
+
+	if !c.plural {
+
+		for i := 0; i < len(c.keys); i++ {
+			if c.keys[i] == value {
+				return i
+			}
+		}
+
+		return c.other
+	}
+
+	ops, ok := parsePluralOperands(value)
+
+	// not a number
+	if !ok {
+		return c.other
+	}
+
+	// explicit values go first
+	for i := 0; i < len(c.keys); i++ {
+		if key := c.keys[i]; (key[0] == charExactValue) && ops.equal(key[1:]) {
+			return i
+		}
+	}
+
+	category := rule(ops)
+
+	for i := 0; i < len(c.keys); i++ {
+		if c.keys[i] == category {
+			return i
+		}
+	}
+
+	return c.other
+}
+
+// SEE (*xfmt).Sprint()
+func (fmt *xfmt) sprintMessage(lang string, args *messageArgs) (s string) {
+
+	// fast-paths
+	// - format is empty string
+	if len(fmt.tokens) == 0 {
+		return ""
+	}
+
+	// - format is a single raw const string value without any argument
+	if (len(fmt.tokens) == 1) && (fmt.tokens[0].verb == verbNone) {
+		return fmt.tokens[0].value
+	}
+
+	b := fmtprintbufpool.Get()
+
+	b.Grow(fmt.minSize)
+
+	fmt.bprintMessageTo(b, fmt.tokens, args, pluralRuleOf(lang))
+
+	// WARN make string from buf BEFORE return buf to pool
+	s = b.String()
+
+	b.Free()
+
+	return s
+}
+
+// FormatMessage formats according to message format ("{0}", "{0, plural, one {# file} other {# files}}",
+// "{0, select, female {her} other {his}}") using plural rules of lang, args are referred by 0-based index
+func FormatMessage(lang, pattern string, args ...string) string {
+	xfmt := messageFrontend.forge(pattern, false)
+	return xfmt.sprintMessage(lang, &messageArgs{args: args})
+}
+
+// FormatMessageMap is FormatMessage with named arguments ("{count, plural, ...}") taking values from args
+func FormatMessageMap(lang, pattern string, args map[string]string) string {
+	xfmt := messageFrontend.forge(pattern, false)
+	return xfmt.sprintMessage(lang, &messageArgs{named: mapArgs(args)})
+}
+
+//
+
+// pluralOperands are CLDR plural operands of decimal number
+// SEE https://unicode.org/reports/tr35/tr35-numbers.html#Operands
+// WARN only the last 18 digits of integer and fraction parts are kept, that is enough for the rules
+type pluralOperands struct {
+	i uint64 // integer digits
+	v int    // number of visible fraction digits
+	f uint64 // visible fraction digits, 0 means that the number is integer
+	// sign isn't CLDR operand (plural rules use absolute value), it's used only by explicit values
+	neg bool
+}
+
+const maxPluralDigits = 1e18
+
+// parsePluralOperands parses decimal number "[+-]digits[.digits]"
+//go:nosplit
+func parsePluralOperands(s string) (ops pluralOperands, ok bool) {
+
+	if (s != "") && ((s[0] == '-') || (s[0] == '+')) {
+		ops.neg, s = s[0] == '-', s[1:]
+	}
+
+	i, digits := 0, 0
+
+	for ; (i < len(s)) && ('0' <= s[i]) && (s[i] <= '9'); i++ {
+		ops.i = (ops.i*10 + uint64(s[i]-'0')) % maxPluralDigits
+		digits++
+	}
+
+	if (i < len(s)) && (s[i] == charDot) {
+
+		for i++; (i < len(s)) && ('0' <= s[i]) && (s[i] <= '9'); i++ {
+			ops.f = (ops.f*10 + uint64(s[i]-'0')) % maxPluralDigits
+			ops.v++
+		}
+	}
+
+	return ops, (i == len(s)) && (digits+ops.v > 0)
+}
+
+// equal reports whether the number is equal to decimal number s
+//go:nosplit
+func (ops *pluralOperands) equal(s string) bool {
+
+	other, ok := parsePluralOperands(s)
+
+	if !ok || (ops.i != other.i) || (ops.neg != other.neg) {
+		return false
+	}
+
+	// fraction digits are compared without trailing zeros ("1.50" == "1.5")
+	return trimZeros(ops.f) == trimZeros(other.f)
+}
+
+// inlined
+//go:nosplit
+func trimZeros(n uint64) uint64 {
+
+	for (n != 0) && (n%10 == 0) {
+		n /= 10
+	}
+
+	return n
+}
+
+// inlined
+//go:nosplit
+func (ops *pluralOperands) integer() bool {
+	return ops.f == 0
+}
+
+// CLDR plural categories
+const (
+	pluralZero = "zero"
+	pluralOne  = "one"
+	pluralTwo  = "two"
+	pluralFew  = "few"
+	pluralMany = "many"
+)
+
+// pluralRule returns CLDR plural category of the number
+type pluralRule = func(ops pluralOperands) string
+
+// pluralRules are CLDR plural rules of cardinals
+// SEE https://unicode.org/cldr/charts/latest/supplemental/language_plural_rules.html
+var pluralRules = map[string]pluralRule{
+	"en": pluralRuleEn,
+	"ru": pluralRuleRu,
+	"pl": pluralRulePl,
+	"ar": pluralRuleAr,
+	"ja": pluralRuleOther,
+}
+
+// pluralRuleOf returns plural rule of lang or its base language, CLDR root rule (always "other") for unknown ones
+//go:nosplit
+func pluralRuleOf(lang string) pluralRule {
+
+	if rule, ok := pluralRules[lang]; ok {
+		return rule
+	}
+
+	if rule, ok := pluralRules[baseLang(lang)]; ok {
+		return rule
+	}
+
+	return pluralRuleOther
+}
+
+// ja, root: other
+func pluralRuleOther(pluralOperands) string {
+	return messageOther
+}
+
+// en: one - i = 1 and v = 0
+func pluralRuleEn(ops pluralOperands) string {
+
+	if (ops.i == 1) && (ops.v == 0) {
+		return pluralOne
+	}
+
+	return messageOther
+}
+
+// ru: one - v = 0 and i % 10 = 1 and i % 100 != 11
+//     few - v = 0 and i % 10 = 2..4 and i % 100 != 12..14
+//     many - v = 0 and (i % 10 = 0 or i % 10 = 5..9 or i % 100 = 11..14)
+func pluralRuleRu(ops pluralOperands) string {
+
+	if ops.v != 0 {
+		return messageOther
+	}
+
+	i10, i100 := ops.i%10, ops.i%100
+
+	switch {
+	case (i10 == 1) && (i100 != 11):
+		return pluralOne
+	case (2 <= i10) && (i10 <= 4) && ((i100 < 12) || (i100 > 14)):
+		return pluralFew
+	}
+
+	return pluralMany
+}
+
+// pl: one - i = 1 and v = 0
+//     few - v = 0 and i % 10 = 2..4 and i % 100 != 12..14
+//     many - v = 0 and (i != 1 and i % 10 = 0..1 or i % 10 = 5..9 or i % 100 = 12..14)
+func pluralRulePl(ops pluralOperands) string {
+
+	if ops.v != 0 {
+		return messageOther
+	}
+
+	i10, i100 := ops.i%10, ops.i%100
+
+	switch {
+	case ops.i == 1:
+		return pluralOne
+	case (2 <= i10) && (i10 <= 4) && ((i100 < 12) || (i100 > 14)):
+		return pluralFew
+	}
+
+	return pluralMany
+}
+
+// ar: zero - n = 0
+//     one - n = 1
+//     two - n = 2
+//     few - n % 100 = 3..10
+//     many - n % 100 = 11..99
+func pluralRuleAr(ops pluralOperands) string {
+
+	if !ops.integer() {
+		return messageOther
+	}
+
+	switch n100 := ops.i % 100; {
+	case ops.i == 0:
+		return pluralZero
+	case ops.i == 1:
+		return pluralOne
+	case ops.i == 2:
+		return pluralTwo
+	case (3 <= n100) && (n100 <= 10):
+		return pluralFew
+	case 11 <= n100:
+		return pluralMany
+	}
+
+	return messageOther
+}
@@ -0,0 +1,144 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"testing"
+)
+
+// go test -count=1 -v -run "^TestFormatMessage$"
+func TestFormatMessage(t *testing.T) {
+
+	const files = "{0, plural, =0 {no files} one {# file} few {# файла} many {# файлов} other {# files}}"
+
+	cases := [...]struct {
+		lang, pattern string
+		args          []string
+		want          string
+	}{
+		{"en", "", nil, ""},
+		{"en", "no args", nil, "no args"},
+		{"en", "user {0} logged in from {1}", []string{"bob", "host"}, "user bob logged in from host"},
+		{"en", "{1} and {0} and {1}", []string{"a", "b"}, "b and a and b"},
+		{"en", "{0} and {1}", []string{"a"}, "a and %!v(MISSING)"},
+
+		{"en", files, []string{"0"}, "no files"},
+		{"en", files, []string{"1"}, "1 file"},
+		{"en", files, []string{"5"}, "5 files"},
+		{"en", files, []string{"1.0"}, "1.0 files"},
+		{"en-US", files, []string{"1"}, "1 file"},
+		{"en", files, []string{"many"}, "many files"},
+		{"en", files, []string{"-1"}, "-1 file"},
+
+		{"ru", files, []string{"1"}, "1 file"},
+		{"ru", files, []string{"21"}, "21 file"},
+		{"ru", files, []string{"11"}, "11 файлов"},
+		{"ru", files, []string{"3"}, "3 файла"},
+		{"ru", files, []string{"13"}, "13 файлов"},
+		{"ru", files, []string{"24"}, "24 файла"},
+		{"ru", files, []string{"1.5"}, "1.5 files"},
+
+		{"pl", files, []string{"1"}, "1 file"},
+		{"pl", files, []string{"21"}, "21 файлов"},
+		{"pl", files, []string{"22"}, "22 файла"},
+		{"pl", files, []string{"12"}, "12 файлов"},
+
+		{"ja", files, []string{"1"}, "1 files"},
+		{"xx", files, []string{"1"}, "1 files"},
+
+		{"ar", "{0, plural, zero {z} one {o} two {t} few {f} many {m} other {x}}", nil, "%!v(MISSING)"},
+
+		{"en", "{g, select, female {her} male {his} other {their}} {0}", []string{"x"}, "%!v(MISSING g) x"},
+		{"en", "{0, select, female {her} male {his} other {their}}", []string{"female"}, "her"},
+		{"en", "{0, select, female {her} male {his} other {their}}", []string{"it"}, "their"},
+
+		// nested clauses, "#" of the innermost plural
+		{"en", "{1, select, female {{0, plural, one {she has # file} other {she has # files}}} " +
+			"other {{0, plural, one {they have # file} other {they have # files}}}}", []string{"3", "female"},
+			"she has 3 files"},
+		{"en", "{0, plural, other {{1, plural, other {#}} #}}", []string{"1", "2"}, "2 1"},
+
+		// quoting
+		{"en", "it''s {0}", []string{"x"}, "it's x"},
+		{"en", "it's {0}", []string{"x"}, "it's x"},
+		{"en", "'{0}' is {0}", []string{"x"}, "{0} is x"},
+		{"en", "'{it''s}' {0}", []string{"x"}, "{it's} x"},
+		{"en", "{0, plural, other {'#' is #}}", []string{"7"}, "# is 7"},
+		{"en", "# and '#'", nil, "# and '#'"},
+		{"en", "unfinished '{quote", nil, "unfinished {quote"},
+		{"en", "brace } is raw", nil, "brace } is raw"},
+
+		// errors
+		{"en", "{} x", nil, "%!(BADSPEC) x"},
+		{"en", "{0, number} x", []string{"1"}, "%!(BADSPEC) x"},
+		{"en", "{0, plural, one {x}} y", []string{"1"}, "%!(BADSPEC) y"},
+		{"en", "{0, plural, bad {x} other {y}} z", []string{"1"}, "%!(BADSPEC) z"},
+		{"en", "{0, plural, =x {x} other {y}} z", []string{"1"}, "%!(BADSPEC) z"},
+		{"en", "{0, select, a {x} other {{1, foo}}} z", []string{"b"}, "%!(BADSPEC) z"},
+		{"en", "x {0", nil, "x %!(NOVERB)"},
+		{"en", "x {0, plural, other {y}", nil, "x %!(NOVERB)"},
+	}
+
+	for _, c := range cases {
+		if got := FormatMessage(c.lang, c.pattern, c.args...); got != c.want {
+			t.Errorf("%s %q %q: mismatch result: want <%s>, got <%s>", c.lang, c.pattern, c.args, c.want, got)
+		}
+	}
+
+	args := []string{"bob", "5"}
+
+	// the only memalloc is the result string
+	assertMallocs(t, "FormatMessage", 1, func() {
+		_ = FormatMessage("en", "{0} has {1, plural, one {# file} other {# files}}", args...)
+	})
+}
+
+// go test -count=1 -v -run "^TestFormatMessageAr$"
+func TestFormatMessageAr(t *testing.T) {
+
+	const pattern = "{n, plural, zero {z} one {o} two {t} few {f} many {m} other {x}}"
+
+	cases := map[string]string{
+		"0": "z", "1": "o", "2": "t", "3": "f", "10": "f", "11": "m", "99": "m", "100": "x", "102": "x",
+		"103": "f", "111": "m", "1.0": "o", "1.5": "x", "0.00": "z",
+	}
+
+	for n, want := range cases {
+		if got := FormatMessageMap("ar", pattern, map[string]string{"n": n}); got != want {
+			t.Errorf("%s: mismatch result: want <%s>, got <%s>", n, want, got)
+		}
+	}
+}
+
+// go test -count=1 -v -run "^TestFormatMessageExactValues$"
+func TestFormatMessageExactValues(t *testing.T) {
+
+	const pattern = "{0, plural, =1.5 {one and a half} =-1 {minus one} =1 {exactly one} one {one} other {#}}"
+
+	cases := map[string]string{
+		"1": "exactly one", "1.0": "exactly one", "1.50": "one and a half", "-1": "minus one", "+1": "exactly one",
+		"2": "2",
+	}
+
+	for n, want := range cases {
+		if got := FormatMessage("en", pattern, n); got != want {
+			t.Errorf("%s: mismatch result: want <%s>, got <%s>", n, want, got)
+		}
+	}
+}
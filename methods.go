@@ -0,0 +1,222 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"math"
+	"unsafe"
+)
+
+// INFO: error and Stringer args
+//      Err and Stringer args (and Any args of such types) call their Error() and String() methods for `%s`, `%q`,
+//      `%x`, `%X` and `%v` verbs and format the result as a string arg. Like std `fmt`, panics of the methods are
+//      recovered: the panic of nil pointer receiver is printed as "<nil>", others as %!v(PANIC=String method: ...).
+//      Neither reflect nor runtime type layout is used, so any nil pointer-shaped receiver is taken as nil pointer:
+//      unlike std `fmt` panics of nil map, chan and func receivers are printed as "<nil>" too (SEE isNilData())
+
+// stringer is the same as fmt.Stringer, which isn't used to not to import `fmt`
+type stringer interface {
+	String() string
+}
+
+// Err returns Arg of error value
+// inlined
+//go:nosplit
+func Err(err error) Arg {
+
+	if err == nil {
+		return Arg{kind: argNil}
+	}
+
+	return Arg{v: err, kind: argError}
+}
+
+// Stringer returns Arg of value with String method (e.g. fmt.Stringer)
+// inlined
+//go:nosplit
+func Stringer(s interface{ String() string }) Arg {
+
+	if s == nil {
+		return Arg{kind: argNil}
+	}
+
+	return Arg{v: s, kind: argStringer}
+}
+
+// Any returns Arg of appropriate kind for value of any type supported by the *A fns using type switch only (no
+// reflect), values of other types are formatted as `%!v(?=?)`
+// NOTE like std `fmt`, Appender, error and Stringer are checked only for types, which aren't basic types
+//go:nosplit
+func Any(v interface{}) Arg {
+
+	switch v := v.(type) {
+	case nil:
+		return Arg{kind: argNil}
+	case string:
+		return Str(v)
+	case []byte:
+		return Bytes(v)
+	case int:
+		return Int(int64(v))
+	case int8:
+		return Int(int64(v))
+	case int16:
+		return Int(int64(v))
+	case int32:
+		return Int(int64(v))
+	case int64:
+		return Int(v)
+	case uint:
+		return Uint(uint64(v))
+	case uint8:
+		return Uint(uint64(v))
+	case uint16:
+		return Uint(uint64(v))
+	case uint32:
+		return Uint(uint64(v))
+	case uint64:
+		return Uint(v)
+	case uintptr:
+		return Uint(uint64(v))
+	case float32:
+		return Arg{u: math.Float64bits(float64(v)), kind: argFloat32}
+	case float64:
+		return Float(v)
+	case bool:
+		return Bool(v)
+	case Appender:
+		return Value(v)
+	case error:
+		return Err(v)
+	case stringer:
+		return Stringer(v)
+	}
+
+	return Arg{v: v, kind: argUnsupported}
+}
+
+// strings of panic marks
+const (
+	panicString        = "(PANIC="
+	errorMethodString  = "Error method: "
+	stringMethodString = "String method: "
+	nestedPanicString  = "(nested panic)"
+)
+
+// SEE src/fmt/print.go::(*pp).handleMethods()
+func (token *token) fmtMethod(buf *buffer, arg *Arg, flags flags, width, prec int) bool {
+
+	switch token.verb {
+	case verbValue, verbString, verbQuoted, verbHex:
+	default:
+		token.badVerbA(buf, arg)
+		return false
+	}
+
+	s, ok := callMethod(buf, token.verbString(), arg)
+
+	if !ok {
+		return false
+	}
+
+	return token.fmtString(buf, s, flags, width, prec)
+}
+
+// callMethod calls Error() or String() method of arg, its panic is recovered and printed to buf as a panic of
+// `verb` formatting
+// SEE src/fmt/print.go::(*pp).catchPanic()
+func callMethod(buf *buffer, verb string, arg *Arg) (s string, ok bool) {
+
+	defer func() {
+		if err := recover(); err != nil {
+			catchPanic(buf, verb, arg, err)
+		}
+	}()
+
+	if arg.kind == argError {
+		return arg.v.(error).Error(), true
+	}
+
+	return arg.v.(stringer).String(), true
+}
+
+// SEE src/fmt/print.go::(*pp).catchPanic()
+func catchPanic(buf *buffer, verb string, arg *Arg, err interface{}) {
+
+	// If it's a nil pointer, just say "<nil>".
+	if isNilData(arg.v) {
+		buf.WriteString(nilAngleString)
+		return
+	}
+
+	// Otherwise print a concise panic message. Most of the time the panic value will print itself nicely.
+	buf.WriteString(percentBangString)
+	buf.WriteString(verb)
+	buf.WriteString(panicString)
+
+	if arg.kind == argError {
+		buf.WriteString(errorMethodString)
+	} else {
+		buf.WriteString(stringMethodString)
+	}
+
+	buf.Write(appendPanicValue(buf.TempBuf(), err))
+
+	buf.WriteString(rightParensStr)
+}
+
+// isNilData reports whether non-nil v holds nil value of pointer-shaped type
+// NOTE the data word of interface value with a pointer (or other pointer-shaped type: map, chan, func) is the pointer
+//      itself, values of other types are stored indirectly, so their data word is never nil
+// inlined
+//go:nosplit
+func isNilData(v interface{}) bool {
+
+	e := (*[2]unsafe.Pointer)(unsafe.Pointer(&v))
+
+	return (e[0] != nil) && (e[1] == nil)
+}
+
+// writeValue writes arg value in default format (%v) to buf, panics of the methods are recovered
+func (arg *Arg) writeValue(buf *buffer) {
+
+	if (arg.kind != argError) && (arg.kind != argStringer) {
+		buf.Write(arg.appendValue(buf.TempBuf()))
+		return
+	}
+
+	if s, ok := callMethod(buf, verbValueString, arg); ok {
+		buf.WriteString(s)
+	}
+}
+
+// appendPanicValue appends panic value in default format (%v) to b
+// NOTE unlike std `fmt` nested panic of panic value's methods is not propagated, but printed as nestedPanicString
+func appendPanicValue(b []byte, err interface{}) (result []byte) {
+
+	defer func() {
+		if recover() != nil {
+			result = append(b, nestedPanicString...)
+		}
+	}()
+
+	arg := Any(err)
+
+	return arg.appendValue(b)
+}
@@ -0,0 +1,227 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+type testStringer struct {
+	s string
+}
+
+func (ts *testStringer) String() string {
+	return ts.s
+}
+
+type testPanicStringer struct{}
+
+func (testPanicStringer) String() string {
+	panic("boom")
+}
+
+// NOTE nil map receiver has nil data word of interface value as nil pointer one, so its panic is printed as "<nil>"
+type testMapStringer map[string]int
+
+func (m testMapStringer) String() string {
+	m["calls"]++
+	return "map"
+}
+
+// NOTE value method called using nil pointer panics in autogenerated pointer wrapper
+type testValueStringer struct{}
+
+func (testValueStringer) String() string {
+	return "value"
+}
+
+type testPanicError struct{}
+
+func (*testPanicError) Error() string {
+	panic(errors.New("nested"))
+}
+
+// go test -count=1 -v -run "^TestMethodsWithFmtPkg$"
+func TestMethodsWithFmtPkg(t *testing.T) {
+
+	err, ts := errors.New("some error"), &testStringer{"Привет, мир"}
+
+	formats := [...]string{
+		"%s", "%v", "%q", "%+q", "%#q", "%x", "%X", "% x", "%#x", "%10s", "%-10s|", "%.3s", "%10.3v", "%010s",
+	}
+
+	for _, format := range formats {
+
+		for _, c := range [...]struct {
+			a Arg
+			v interface{}
+		}{
+			{Err(err), err},
+			{Stringer(ts), ts},
+			{Any(err), err},
+			{Any(ts), ts},
+		} {
+
+			want, got := fmt.Sprintf(format, c.v), SprintfA(format, c.a)
+
+			if got != want {
+				t.Errorf("%q %T: mismatch result: want <%s>, got <%s>", format, c.v, want, got)
+			}
+		}
+	}
+
+	assertMallocs(t, "FprintfA of error", 0, func() {
+		_, _ = FprintfA(mallocWriter{}, "dial failed: %v", Err(err))
+	})
+}
+
+// go test -count=1 -v -run "^TestMethodsPanics$"
+func TestMethodsPanics(t *testing.T) {
+
+	var (
+		nilStringer *testStringer
+		nilErr      *testPanicError
+		nilMap      testMapStringer
+		nilValue    *testValueStringer
+	)
+
+	cases := [...]struct {
+		format string
+		args   []Arg
+		iargs  []interface{}
+	}{
+		{"%s", []Arg{Stringer(nilStringer)}, []interface{}{nilStringer}},
+		{"%v|", []Arg{Any(nilStringer)}, []interface{}{nilStringer}},
+		{"%s", []Arg{Stringer(testPanicStringer{})}, []interface{}{testPanicStringer{}}},
+		{"%10q|", []Arg{Stringer(testPanicStringer{})}, []interface{}{testPanicStringer{}}},
+		{"%x", []Arg{Any(testPanicStringer{})}, []interface{}{testPanicStringer{}}},
+		{"%v", []Arg{Err(&testPanicError{})}, []interface{}{&testPanicError{}}},
+		{"%v", []Arg{Err(nilErr)}, []interface{}{nilErr}},
+		{"%s", []Arg{Stringer(nilValue)}, []interface{}{nilValue}},
+		{"%v", []Arg{Err(nil)}, []interface{}{error(nil)}},
+		{"%s", []Arg{Err(nil)}, []interface{}{error(nil)}},
+		{"%s|%s", []Arg{Str("a"), Stringer(testPanicStringer{}), Str("b")},
+			[]interface{}{"a", testPanicStringer{}, "b"}},
+	}
+
+	for _, c := range cases {
+		if want, got := fmt.Sprintf(c.format, c.iargs...), SprintfA(c.format, c.args...); got != want {
+			t.Errorf("%q: mismatch result: want <%s>, got <%s>", c.format, want, got)
+		}
+	}
+
+	// unlike std `fmt` any nil pointer-shaped receiver is taken as nil pointer
+	for _, a := range [...]Arg{Stringer(nilMap), Any(nilMap)} {
+		if got, want := SprintfA("%s|", a), "<nil>|"; got != want {
+			t.Errorf("nil map: mismatch result: want <%s>, got <%s>", want, got)
+		}
+	}
+
+	// extra args are printed with their interface type name instead of dynamic type name
+	want := "a%!(EXTRA fmt.Stringer=%!v(PANIC=String method: boom))"
+
+	if got := SprintfA("%s", Str("a"), Stringer(testPanicStringer{})); got != want {
+		t.Errorf("extra: mismatch result: want <%s>, got <%s>", want, got)
+	}
+}
+
+// go test -count=1 -v -run "^TestAnyArgs$"
+func TestAnyArgs(t *testing.T) {
+
+	type named int
+
+	values := [...]interface{}{
+		"str", []byte("bytes"), int(-1), int8(-8), int16(16), int32(-32), int64(64), uint(1), uint8(8), uint16(16),
+		uint32(32), uint64(64), uintptr(0xFF), float32(0.1), float64(0.1), true, nil,
+	}
+
+	formats := [...]string{"%v", "%s", "%d", "%x", "%.2f", "%g", "%t", "%6v|"}
+
+	for _, format := range formats {
+		for _, v := range values {
+
+			want, got := fmt.Sprintf(format, v), SprintfA(format, Any(v))
+
+			// []byte is printed by std `fmt` as a list of numbers for `%v` and `%d`
+			if _, ok := v.([]byte); ok && (format != "%s") && (format != "%x") {
+				continue
+			}
+
+			// bad verb marks contain type name, which can't be get without reflect, so compare only up to the mark
+			if i := strings.Index(want, percentBangString); (i >= 0) && strings.HasPrefix(got, want[:i+len(percentBangString)]) {
+				continue
+			}
+
+			if got != want {
+				t.Errorf("%q %#v: mismatch result: want <%s>, got <%s>", format, v, want, got)
+			}
+		}
+	}
+
+	// types unknown to the type switch are not supported
+	if got, want := SprintfA("%v", Any(named(1))), "%!v(?=?)"; got != want {
+		t.Errorf("unsupported type: mismatch result: want <%s>, got <%s>", want, got)
+	}
+}
+
+// go test -count=1 -v -run "^TestIsNilData$"
+func TestIsNilData(t *testing.T) {
+
+	var (
+		p  *int
+		m  map[string]int
+		c  chan int
+		f  func()
+		s  []int
+		up unsafe.Pointer
+	)
+
+	cases := [...]struct {
+		v    interface{}
+		want bool
+	}{
+		{nil, false},
+		{p, true},
+		{&p, false},
+		{m, true},
+		{c, true},
+		{f, true},
+		{s, false},
+		{up, true},
+		{0, false},
+		{false, false},
+		{"", false},
+		{struct{}{}, false},
+		{testPanicStringer{}, false},
+		{testMapStringer(nil), true},
+		{(*testStringer)(nil), true},
+		{&testStringer{}, false},
+		{errors.New("err"), false},
+	}
+
+	for i, c := range cases {
+		if got := isNilData(c.v); got != c.want {
+			t.Errorf("%d (%T): isNilData mismatch: want %t, got %t", i, c.v, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+// INFO: named placeholders
+//      Named placeholder "%{name}s" takes its arg by name instead of position, so long templates stay readable.
+//      Flags, width and precision go either before or after the name ("%-{name}10s", "%{name}-10.3s") and are
+//      applied to the arg as usual. The same name may be used many times and placeholders may be mixed with raw
+//      text and "%%". Named placeholders consume no positional args, so positional fns print them as missing args
+//      and positional verbs inside named formats are printed as missing args too
+
+// arg indexes of named placeholders are `namedArgBase + index of the name inside xfmt.names`, so they are always
+// out of bounds of positional args (maxNum is far less)
+const namedArgBase uint32 = 1 << 31
+
+// namedArgs is the source of values of named placeholders
+type namedArgs interface {
+	lookup(name string) (value string, ok bool)
+}
+
+type mapArgs map[string]string
+
+// inlined
+//go:nosplit
+func (m mapArgs) lookup(name string) (value string, ok bool) {
+	value, ok = m[name]
+	return value, ok
+}
+
+type funcArgs func(name string) (string, bool)
+
+// inlined
+//go:nosplit
+func (f funcArgs) lookup(name string) (value string, ok bool) {
+	return f(name)
+}
+
+// SEE (*token).missingArg()
+//go:nosplit
+func (token *token) missingName(buf *buffer, name string) {
+
+	buf.WriteString(percentBangString)
+	buf.WriteString(token.verbString())
+	buf.WriteString(missingNameString)
+	buf.WriteString(name)
+	buf.WriteString(rightParensStr)
+}
+
+// SEE bprintTo()
+func (fmt *xfmt) bprintNamedTo(buf *buffer, args namedArgs) {
+
+	// try to minimize memallocs
+	buf.Grow(fmt.minSize)
+
+	// arg of the current named placeholder
+	var arg [1]string
+
+	for i := 0; i < len(fmt.tokens); i++ {
+
+		token := &fmt.tokens[i]
+
+		if token.verb == verbGroup {
+			i += fmt.skipGroupNamed(fmt.tokens, i, args)
+			continue
+		}
+
+		// raw const string values and positional verbs (which have no args at all)
+		if (token.verb == verbNone) || (token.arg < namedArgBase) {
+			token.format(buf, nil)
+			continue
+		}
+
+		name := fmt.names[token.arg-namedArgBase]
+
+		value, ok := args.lookup(name)
+
+		if !ok {
+			token.missingName(buf, name)
+			continue
+		}
+
+		// named token is formatted as positional one with single arg
+		tok := *token
+		tok.arg, arg[0] = 0, value
+
+		tok.format(buf, arg[:])
+	}
+}
+
+// SEE (*xfmt).Sprint()
+func (fmt *xfmt) sprintNamed(args namedArgs) (s string) {
+
+	// fast-paths
+	// - format is empty string
+	if len(fmt.tokens) == 0 {
+		return ""
+	}
+
+	// - format is a single raw const string value without any verb
+	if (len(fmt.tokens) == 1) && (fmt.tokens[0].verb == verbNone) {
+		return fmt.tokens[0].value
+	}
+
+	b := fmtprintbufpool.Get()
+
+	fmt.bprintNamedTo(b, args)
+
+	// WARN make string from buf BEFORE return buf to pool
+	s = b.String()
+
+	b.Free()
+
+	return s
+}
+
+// SprintfMap formats according to format with named placeholders "%{name}s" taking values from args, missing
+// names are printed as "%!s(MISSING name)"
+func SprintfMap(format string, args map[string]string) string {
+	xfmt := forgeXfmt(format)
+	return xfmt.sprintNamed(mapArgs(args))
+}
+
+// SprintfFunc is like SprintfMap, but values are returned by lookup, which is called for every named placeholder
+// (i.e. as many times as the name is used) and reports whether the name exists
+func SprintfFunc(format string, lookup func(name string) (string, bool)) string {
+	xfmt := forgeXfmt(format)
+	return xfmt.sprintNamed(funcArgs(lookup))
+}
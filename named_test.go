@@ -0,0 +1,117 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"testing"
+)
+
+// go test -count=1 -v -run "^TestSprintfMap$"
+func TestSprintfMap(t *testing.T) {
+
+	defer SetCacheThreshold(CacheThreshold())
+
+	args := map[string]string{"user": "bob", "greet": "Привет", "empty": ""}
+
+	cases := [...]struct {
+		format string
+		want   string
+	}{
+		{"", ""},
+		{"no placeholders", "no placeholders"},
+		{"hi, %{user}s!", "hi, bob!"},
+		{"%{user}s and %{user}q", `bob and "bob"`},
+		{"[%{user}-6s][%{user}6s][%-{user}6s]", "[bob   ][   bob][bob   ]"},
+		{"[%{greet}.3s][%{greet}8.2s][%{greet}x]", "[При][      Пр][d09fd180d0b8d0b2d0b5d182]"},
+		{"[%{greet}+q][%{user}v][%{empty}s]", `["\u041f\u0440\u0438\u0432\u0435\u0442"][bob][]`},
+		{"100%% of %{user}s", "100% of bob"},
+		{"%{none}s|%{none}-10q|", "%!s(MISSING none)|%!q(MISSING none)|"},
+		{"%{user}d", "%!d(string=bob)"},
+		{"%{user}[1]s", "%!s(BADINDEX)"},
+		{"%s %{user}s", "%!s(MISSING) bob"},
+		{"%{user", "%!{(MISSING)user"},
+	}
+
+	for _, threshold := range [...]uint{CacheAlways, CacheRepetitions, CacheDisabled} {
+
+		purgeCaches()
+
+		SetCacheThreshold(threshold)
+
+		for i := 0; i < 3; i++ {
+			for _, c := range cases {
+				if got := SprintfMap(c.format, args); got != c.want {
+					t.Errorf("%d: %q: mismatch result: want <%s>, got <%s>", threshold, c.format, c.want, got)
+				}
+			}
+		}
+
+		if threshold == CacheDisabled {
+			continue
+		}
+
+		// the only memalloc is the result string
+		assertMallocs(t, "SprintfMap", 1, func() {
+			_ = SprintfMap("hi, %{user}s!", args)
+		})
+	}
+}
+
+// go test -count=1 -v -run "^TestSprintfFunc$"
+func TestSprintfFunc(t *testing.T) {
+
+	calls := 0
+
+	lookup := func(name string) (string, bool) {
+
+		calls++
+
+		if name == "id" {
+			return "42", true
+		}
+
+		return "", false
+	}
+
+	if got, want := SprintfFunc("%{id}s/%{id}05s/%{x}s", lookup), "42/00042/%!s(MISSING x)"; got != want {
+		t.Errorf("mismatch result: want <%s>, got <%s>", want, got)
+	}
+
+	if calls != 3 {
+		t.Errorf("lookup calls: want 3, got %d", calls)
+	}
+}
+
+// go test -count=1 -v -run "^TestNamedPositional$"
+func TestNamedPositional(t *testing.T) {
+
+	defer SetCacheThreshold(CacheThreshold())
+
+	// named placeholders consume no positional args
+	for _, threshold := range [...]uint{CacheAlways, CacheDisabled} {
+
+		purgeCaches()
+
+		SetCacheThreshold(threshold)
+
+		if got, want := Sprintf("%{user}s %s", "a"), "%!s(MISSING) a"; got != want {
+			t.Errorf("%d: mismatch result: want <%s>, got <%s>", threshold, want, got)
+		}
+	}
+}
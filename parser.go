@@ -25,17 +25,95 @@ import (
 	"unicode/utf8"
 )
 
+// state of the last appended token for merging of adjacent raw const string values (including "%%")
+type literal struct {
+	open       bool // last token is raw const string value, which may be merged with the next one
+	start, end int  // bounds of the last token value inside the format string, `end == -1` if it isn't a substring
+}
+
+// appendLiteral appends raw const string value `format[start:end]` to `tokens` merging it with the previous raw
+// const string value token if any; merged value is resliced from the `format` without memalloc if both parts are
+// adjacent inside the `format`, and only otherwise new string is allocated
+//go:nosplit
+func appendLiteral(tokens []token, lit *literal, format string, start, end int) []token {
+
+	if n := len(tokens); lit.open && (n > 0) {
+
+		last := &tokens[n-1]
+
+		if lit.end == start {
+			// adjacent parts, no memalloc
+			last.value = format[lit.start:end]
+			lit.end = end
+		} else {
+			last.value += format[start:end]
+			lit.end = -1
+		}
+
+		return tokens
+	}
+
+	lit.open, lit.start, lit.end = true, start, end
+
+	return append(tokens, token{
+		verb:  verbNone,
+		value: format[start:end],
+	})
+}
+
+// appendPercent appends "%" of the percent verb that starts at `pos` and ends at `pos+i` inside the `format`
+// NOTE either the first or the last char '%' is used as value, so it is always adjacent to either previous or next
+//      raw const string value
+// inlined
+//go:nosplit
+func appendPercent(tokens []token, lit *literal, format string, pos, i int) []token {
+
+	if !lit.open || (lit.end != pos) {
+		pos += i
+	}
+
+	return appendLiteral(tokens, lit, format, pos, pos+len(percentString))
+}
+
 // NOTE return nil `xfmt.tokens` slice for empty format string
 // NOTE retval by value
 func parseFormat(format string) xfmt {
+	return parseFormatWith(format, nil)
+}
+
+// parseFormatWith parses format resolving included formats by inc, nil inc means registered formats (SEE include.go)
+// NOTE retval by value
+func parseFormatWith(format string, inc includer) xfmt {
 
 	needArgs, curArg, minSize := 0, 0, 0
 
-	var tokens []token
+	var (
+		tokens []token
+		lit    literal
+		names  []string
+		groups []openGroup // stack of conditional groups being parsed, SEE group.go
+	)
+
+	// original format string for merging of adjacent raw const string values
+	orig := format
 
 	if format != "" {
-		// use `make` with cap adjusted by counting '%', excluding double counting for "%%" (approximate count)
-		if tokApxCount := strings.Count(format, percentStr) - strings.Count(format, doublePercentStr); tokApxCount > 0 {
+		// use `make` with cap adjusted by counting '%', excluding double counting for "%%" (approximate count of
+		// verbs), and raw const string values between verbs, before the first one and after the last one, so
+		// reallocations of tokens slice are avoided
+		if verbsApxCount := strings.Count(format, percentStr) - strings.Count(format, doublePercentStr); verbsApxCount > 0 {
+
+			tokApxCount := 2*verbsApxCount - 1
+
+			if format[0] != charPercent {
+				tokApxCount++
+			}
+
+			// is the last char not an ascii letter (i.e. not a verb)?
+			if c := format[len(format)-1] | ('a' - 'A'); (c < 'a') || (c > 'z') {
+				tokApxCount++
+			}
+
 			tokens = make([]token, 0, tokApxCount)
 		}
 	}
@@ -44,6 +122,50 @@ func parseFormat(format string) xfmt {
 parseLoop:
 	for format != "" /* implies `len(format) > 0` */ {
 
+		// raw text inside conditional group ends at either '%' or paren
+		if (len(groups) != 0) && (format[0] != charPercent) {
+
+			g := &groups[len(groups)-1]
+
+			i := strings.IndexAny(format, groupTextStops)
+
+			if i == -1 {
+				i = len(format)
+			}
+
+			closing := (i < len(format)) && (format[i] == charCloseParen) && (g.parens == 0)
+
+			// raw parens are counted and kept as raw text
+			if (i < len(format)) && !closing && (format[i] != charPercent) {
+
+				if format[i] == charOpenParen {
+					g.parens++
+				} else {
+					g.parens--
+				}
+
+				i++
+			}
+
+			if i > 0 {
+				pos := len(orig) - len(format)
+				tokens = appendLiteral(tokens, &lit, orig, pos, pos+i)
+				minSize += i
+			}
+
+			// closing paren of the group isn't a part of the result, raw text of the group isn't counted by min size
+			if closing {
+				g.close(tokens)
+				minSize, groups = g.minSize, groups[:len(groups)-1]
+				lit.open = false
+				i++
+			}
+
+			format = format[i:]
+
+			continue parseLoop
+		}
+
 		// fast-check
 		if format[0] != charPercent {
 
@@ -58,10 +180,8 @@ parseLoop:
 			}
 
 			// store raw const direct string part into tokens
-			tokens = append(tokens, token{
-				verb:  verbNone,
-				value: format[:i],
-			})
+			pos := len(orig) - len(format)
+			tokens = appendLiteral(tokens, &lit, orig, pos, pos+i)
 
 			// count min size by value len of raw const string token
 			minSize += i
@@ -77,11 +197,85 @@ parseLoop:
 
 		// here format[0] === '%'
 
+		// conditional group "%?(", escaped parens "%(" and "%)" inside groups and included format "%{>name}"
+		if len(format) > 1 {
+			switch c := format[1]; {
+			case (c == charOpenName) && (len(format) > 2) && (format[2] == charInclude):
+
+				end := strings.IndexByte(format, charCloseName)
+
+				// unfinished include is the last token
+				if end == -1 {
+					tokens = append(tokens, tokenErrNoVerb)
+					break parseLoop
+				}
+
+				name := format[3:end]
+
+				// registered formats are loaded only if they are needed
+				if inc == nil {
+					inc = loadFragments()
+				}
+
+				if frag, mark := inc.include(name); frag == nil {
+					tokens = append(tokens, token{verb: verbNone, value: badIncludeValue(mark, name)})
+					minSize += len(tokens[len(tokens)-1].value)
+				} else {
+					tokens, names = includeFormat(tokens, names, frag, curArg)
+					minSize += frag.minSize
+
+					if curArg += int(frag.args); curArg > needArgs {
+						needArgs = curArg
+					}
+				}
+
+				lit.open = false
+				format = format[end+1:]
+
+				continue parseLoop
+
+			case (c == charGroupMark) && (len(format) > 2) && (format[2] == charOpenParen):
+
+				groups = append(groups, openGroup{start: len(tokens), minSize: minSize})
+
+				tokens = append(tokens, token{
+					verb:  verbGroup,
+					value: format[:3],
+					prec:  absentValue,
+				})
+
+				lit.open = false
+				format = format[3:]
+
+				continue parseLoop
+
+			case ((c == charOpenParen) || (c == charCloseParen)) && (len(groups) != 0):
+
+				pos := len(orig) - len(format) + 1
+				tokens = appendLiteral(tokens, &lit, orig, pos, pos+1)
+				minSize++
+				format = format[2:]
+
+				continue parseLoop
+			}
+		}
+
 		flags := flagNone
 
 		// ATN! starts from next char just after '%'
 		i := uint(1) // uint automagically helps BCE optimization without additional conds
 
+		// default value of empty arg "%|n/a|s" is kept inside token value, SEE default.go
+		def := uint(0) // index of the closing pipe, 0 if there is no default
+
+		// NOTE the default can't contain '%', so the closing pipe is never searched inside the next verbs
+		if (len(format) > 2) && (format[1] == charDefault) {
+			if j := strings.IndexAny(format[2:], defaultStops); (j != -1) && (format[2+j] == charDefault) {
+				def = uint(j) + 2
+				i = def + 1
+			}
+		}
+
 		// fast-path simple check
 	fastLoop:
 		for ; i < uint(len(format)); i++ {
@@ -90,16 +284,24 @@ parseLoop:
 
 			// here i < len(format), so we can use i+1 as format' end slice index (`format[i+1:]`)
 
-			switch c {
+			// non-ascii char can't be either flag or simple verb
+			if c >= utf8.RuneSelf {
+				break fastLoop
+			}
+
+			// NOTE zero flag is adjusted after all flags are parsed (see normalizeFlags)
+			if flag := charFlags[c]; flag != flagNone {
+				flags |= flag
+				continue
+			}
 
 			// special case - percent char ("%%" case)
-			case charPercent:
+			if c == charPercent {
 
 				// DOC: Percent does not absorb operands and ignores f.wid and f.prec.
 
-				// emulate percent as no verb raw const string value "%"
-				// TODO? if prev token is verbNone const raw string value, it can be simply merged (append) with '%' char
-				tokens = append(tokens, tokenPercent)
+				// emulate percent as no verb raw const string value "%" merged with adjacent raw const string values
+				tokens = appendPercent(tokens, &lit, orig, len(orig)-len(format), int(i))
 
 				// count min size
 				minSize += len(percentString)
@@ -108,78 +310,82 @@ parseLoop:
 				format = format[i+1:]
 
 				continue parseLoop
+			}
 
-			case flagCharPlus:
-				flags |= flagPlus
-			case flagCharSharp:
-				flags |= flagSharp
-			case flagCharMinus:
-				flags |= flagMinus
-				// DOC: Do not pad with zeros to the right.
-				flags &^= flagZero // exclude zero flag
-			case flagCharSpace:
-				flags |= flagSpace
-			case flagCharZero:
-				// DOC: Only allow zero padding to the left.
-				//p.fmt.zero = !p.fmt.minus
-				if flags&flagMinus == 0 {
-					flags |= flagZero
-				}
-			default:
-				// here i < len(format)
-
-				// Fast path for common case of ascii simple verbs
-				// without precision or width or argument indices.
-				// TODO? see src/strconv/atoi.go::lower()
-				if ('a' <= c) && (c <= 'z') || ('A' <= c) && (c <= 'Z') {
+			// Fast path for common case of ascii simple verbs
+			// without precision or width or argument indices.
+			if verb := charVerbs[c]; verb != verbNone {
 
-					verb, isUpper := char2verb(rune(c))
+				flags = normalizeFlags(flags)
 
-					if isUpper {
-						flags |= flagUpperVerb
-					}
-
-					// append token to tokens
-					tokens = append(tokens, token{
-						verb:  verb,
-						value: format[i : i+1], // slice only verb without flags as token value
-						flags: flags,
-						width: absentValue,  // no width
-						prec:  absentValue,  // no prec
-						arg:   uint(curArg), // NOTE curArg always >= 0
-					})
+				if verb&verbUpper != 0 {
+					verb &^= verbUpper
+					flags |= flagUpperVerb
+				}
 
-					// verb exists, should move curArg forward and check needArgs regardless of whether the verb is known
-					curArg++
+				// append token to tokens
+				tokens = append(tokens, token{
+					verb:  verb,
+					value: format[verbValueStart(i, def) : i+1], // slice only verb without flags as token value
+					flags: flags,
+					width: absentValue,    // no width
+					prec:  absentValue,    // no prec
+					arg:   uint32(curArg), // NOTE curArg always >= 0
+				})
 
-					// here curArg is next using arg num
+				lit.open = false
 
-					if curArg > needArgs {
-						needArgs = curArg
-					}
+				// verb exists, should move curArg forward and check needArgs regardless of whether the verb is known
+				curArg++
 
-					// reslice format next to cur verb
-					format = format[i+1:]
+				// here curArg is next using arg num
 
-					continue parseLoop
+				if curArg > needArgs {
+					needArgs = curArg
 				}
 
-				// DOC: Format is more complex than simple flags and a verb or is malformed.
-				break fastLoop
+				// reslice format next to cur verb
+				format = format[i+1:]
+
+				continue parseLoop
 			}
+
+			// DOC: Format is more complex than simple flags and a verb or is malformed.
+			break fastLoop
 		}
 
+		flags = normalizeFlags(flags)
+
 		// complex case, maybe have width and precision
 		// NOTE ahead forward flags already processed above in fastLoop, so here we stay at
 		// `WidthPrec` or `ArgNum` or format error (i.e utf-8 char or spec char), but not at allowed known `Verb`
 		// because simple direct 'ascii char verb' has been processed in fastLoop
 
+		// named placeholder "%{name}" may be followed by flags like "%{name}-10s", SEE named.go
+		nameIdx := absentValue
+
+		if (i < uint(len(format))) && (format[i] == charOpenName) {
+
+			nameIdx, i, names = tryName(names, format, i)
+
+			for ; (i < uint(len(format))) && (format[i] < utf8.RuneSelf) && (charFlags[format[i]] != flagNone); i++ {
+				flags |= charFlags[format[i]]
+			}
+
+			flags = normalizeFlags(flags)
+		}
+
 		var hasArgNum, properArgNum, properNextArgNum bool
 
 		// all of Width, Precision and ArgNum cases may starts with ArgNum (see ebnf above). Try to parse ArgNum
 
 		curArg, i, hasArgNum, properArgNum = tryArgNum(curArg, format, i)
 
+		// named placeholder can't have arg num ("%{name}[2]s")
+		if hasArgNum && (nameIdx != absentValue) {
+			properArgNum = false
+		}
+
 		// here curArg is parsed cur arg num
 		if hasArgNum && (curArg >= needArgs) {
 			needArgs = curArg + 1
@@ -333,7 +539,8 @@ parseLoop:
 		switch {
 		case verbChar == charPercent:
 			// DOC: Percent does not absorb operands and ignores f.wid and f.prec.
-			tok = tokenPercent
+			// emulate percent as no verb raw const string value "%" merged with adjacent raw const string values
+			tokens = appendPercent(tokens, &lit, orig, len(orig)-len(format), int(i))
 
 			// count min size
 			minSize += len(percentString)
@@ -345,13 +552,30 @@ parseLoop:
 				verb:  verbNone,
 				value: badArgNumValue(verbChar),
 				flags: flags,
-				width: width,
-				prec:  prec,
+				width: int32(width),
+				prec:  int32(prec),
 			}
 
 			// count min size
 			minSize += len(tok.value)
 
+		case nameIdx != absentValue:
+			verb, isUpper := char2verb(verbChar)
+
+			if isUpper {
+				flags |= flagUpperVerb
+			}
+
+			// named placeholder consumes no positional arg, its arg index is out of bounds of any positional args
+			tok = token{
+				verb:  verb,
+				value: format[verbValueStart(i, def) : i+uint(size)],
+				flags: flags,
+				width: int32(width),
+				prec:  int32(prec),
+				arg:   namedArgBase + uint32(nameIdx),
+			}
+
 		default:
 			verb, isUpper := char2verb(verbChar)
 
@@ -360,13 +584,14 @@ parseLoop:
 			}
 
 			// append token to tokens
+			// slice only verb (with default value if any) as token value + don't use `string(verbChar)` to avoid memalloc
 			tok = token{
 				verb:  verb,
-				value: format[i : i+uint(size)], // slice only verb as token value + don't use `string(verbChar)` to avoid memalloc
+				value: format[verbValueStart(i, def) : i+uint(size)],
 				flags: flags,
-				width: width,
-				prec:  prec,
-				arg:   uint(curArg), // NOTE curArg always >= 0
+				width: int32(width),
+				prec:  int32(prec),
+				arg:   uint32(curArg), // NOTE curArg always >= 0
 			}
 
 			// verb exists, should move curArg forward and check lastArg regardless of whether the verb is known
@@ -379,8 +604,11 @@ parseLoop:
 			}
 		}
 
-		// append token to tokens
-		tokens = append(tokens, tok)
+		// append token to tokens (percent is already merged above)
+		if verbChar != charPercent {
+			tokens = append(tokens, tok)
+			lit.open = false
+		}
 
 		// this helps BCE below
 		if (i + uint(size)) >= uint(len(format)) {
@@ -392,22 +620,42 @@ parseLoop:
 	}
 	// fsm ends
 
-	// try to shrink unnecessary tokens slice cap
-	const tokensOversizeThreshold = 1
+	// unclosed groups last up to the end of format
+	for len(groups) != 0 {
 
-	if len(tokens)+tokensOversizeThreshold < cap(tokens) {
-		// compiler optimization CL 146719 make+copy pattern
-		// https://go-review.googlesource.com/c/go/+/146719/
-		tmp := make([]token, len(tokens))
-		copy(tmp, tokens)
+		g := &groups[len(groups)-1]
 
-		tokens = tmp
+		g.close(tokens)
+		minSize, groups = g.minSize, groups[:len(groups)-1]
+
+		tokens = append(tokens, tokenErrNoGroupEnd)
+		minSize += len(noGroupEndString)
 	}
 
+	// NOTE unnecessary tokens slice cap is shrunk only for cached formats (see shrink), because for uncached ones
+	//      (e.g. `CacheDisabled`) the result is used only once
+
 	return xfmt{
 		tokens:  tokens,
 		args:    uint(needArgs), // here needArgs cannot be less than 0
 		minSize: minSize,
+		names:   names,
+	}
+}
+
+// shrink tries to shrink unnecessary tokens slice cap of long-lived (cached) xfmt
+//go:nosplit
+func (fmt *xfmt) shrink() {
+
+	const tokensOversizeThreshold = 1
+
+	if tokens := fmt.tokens; len(tokens)+tokensOversizeThreshold < cap(tokens) {
+		// compiler optimization CL 146719 make+copy pattern
+		// https://go-review.googlesource.com/c/go/+/146719/
+		tmp := make([]token, len(tokens))
+		copy(tmp, tokens)
+
+		fmt.tokens = tmp
 	}
 }
 
@@ -474,6 +722,38 @@ func parseArgNum(format string) (num int, offset uint) {
 	return badArgNum, 1
 }
 
+// tryName parses name of the named placeholder "{name}" starting at `format[i]` and returns its index inside
+// `names` appending the new name if needed; `idx` is absentValue if there is no closing brace
+// NOTE names are substrings of the format string
+//go:nosplit
+func tryName(names []string, format string, i uint) (idx int, j uint, newNames []string) {
+
+	end := strings.IndexByte(format[i+1:], charCloseName)
+
+	if end == -1 {
+		return absentValue, i, names
+	}
+
+	idx, newNames = nameIndex(names, format[i+1:i+1+uint(end)])
+
+	// skip name with both braces
+	return idx, i + 1 + uint(end) + 1, newNames
+}
+
+// nameIndex returns index of the name inside `names` appending the new name if needed
+//go:nosplit
+func nameIndex(names []string, name string) (idx int, newNames []string) {
+
+	// formats have only a few names, so linear search is fast enough
+	for idx = 0; idx < len(names); idx++ {
+		if names[idx] == name {
+			return idx, names
+		}
+	}
+
+	return idx, append(names, name)
+}
+
 // try to parse int value sequence in string `s` from `start` pos up to last seq dec char
 // SEE src/fmt/print.go::parsenum()
 //go:nosplit
@@ -506,23 +786,37 @@ func pickNumValue(s string, start uint) (num int, j uint) {
 
 //
 
-// may return `badVerb` for `verb` that is missing in `verbMap`
+// may return `badVerb` for `verb` that is unknown
 //go:nosplit
 func char2verb(c rune) (verb verb, isUpper bool) {
 
-	// test for upper
-	if isUpper = unicode.IsUpper(c); isUpper {
-		c = unicode.ToLower(c)
+	// non-ascii char is always bad verb
+	if uint32(c) >= uint32(len(charVerbs)) {
+		return badVerb, unicode.IsUpper(c)
 	}
 
-	verb = verbMap[c]
+	verb = charVerbs[c]
 
-	// if wrong verb (not found in verbMap), mark it
+	// non-letter ascii char is also bad verb
 	if verb == verbNone {
-		verb = badVerb
+		return badVerb, false
+	}
+
+	return verb &^ verbUpper, verb&verbUpper != 0
+}
+
+// DOC: Do not pad with zeros to the right.
+// DOC: Only allow zero padding to the left.
+// NOTE regardless of flags order, i.e. the same as `p.fmt.zero = !p.fmt.minus` for '0' and `p.fmt.zero = false` for '-'
+// inlined
+//go:nosplit
+func normalizeFlags(flags flags) flags {
+
+	if flags.has(flagMinus) {
+		flags &^= flagZero
 	}
 
-	return verb, isUpper
+	return flags
 }
 
 //
@@ -21,7 +21,9 @@ package xfmt
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
+	"unsafe"
 )
 
 type flagTestCase struct {
@@ -61,11 +63,11 @@ func (tc *flagTestCase) run( /*t *testing.T*/ ) error {
 		return fmt.Errorf("flags mismatch: want %#x, got %#x", tc.flags, tt.flags)
 	}
 
-	if tt.width != tc.width {
+	if int(tt.width) != tc.width {
 		return fmt.Errorf("width mismatch: want %d, got %d", tc.width, tt.width)
 	}
 
-	if tt.prec != tc.prec {
+	if int(tt.prec) != tc.prec {
 		return fmt.Errorf("prec mismatch: want %d, got %d", tc.prec, tt.prec)
 	}
 
@@ -613,3 +615,100 @@ func TestPickNumValue(t *testing.T) {
 	}
 
 }
+
+// go test -count=1 -v -run "^TestParseFormatLiteralMerge$"
+func TestParseFormatLiteralMerge(t *testing.T) {
+
+	// all parts are adjacent, so merged value must be a substring of the format
+	const format = "%%prefix, %-%"
+
+	x := parseFormat(format)
+
+	if l := len(x.tokens); l != 1 {
+		t.Fatalf("tokens count mismatch: want 1, got %d (%#v)", l, x.tokens)
+	}
+
+	v := x.tokens[0].value
+
+	if want := fmt.Sprintf(hideFromVet(format)); v != want {
+		t.Fatalf("merged value mismatch: want <%s>, got <%s>", want, v)
+	}
+
+	begin := uintptr(*(*unsafe.Pointer)(unsafe.Pointer(&v)))
+	base := uintptr(*(*unsafe.Pointer)(unsafe.Pointer(&[]string{format}[0])))
+
+	if (begin < base) || (begin+uintptr(len(v)) > base+uintptr(len(format))) {
+		t.Fatal("merged value has been allocated instead of reslicing of the format")
+	}
+
+	if got := x.Sprint(nil); got != v {
+		t.Fatalf("result mismatch: want <%s>, got <%s>", v, got)
+	}
+}
+
+// go test -count=1 -v -run "^TestTokenSize$"
+func TestTokenSize(t *testing.T) {
+
+	// string + 3 * int32 + flags + verb
+	if want, got := unsafe.Sizeof("")+4*unsafe.Sizeof(int32(0)), unsafe.Sizeof(token{}); got != want {
+		t.Fatalf("token size mismatch: want %d, got %d", want, got)
+	}
+}
+
+// go test -bench "^BenchmarkParseFormatPercent$" -run "^$" -benchmem
+func BenchmarkParseFormatPercent(b *testing.B) {
+
+	const format = "100%% done: %s"
+
+	if x := parseFormat(format); len(x.tokens) != 2 {
+		b.Fatalf("unexpected tokens count: %d", len(x.tokens))
+	}
+
+	args := []string{strings.Repeat("x", 10)}
+
+	x := parseFormat(format)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		x.bprint(args).Free()
+	}
+}
+
+// go test -bench "^BenchmarkParseFormat$" -run "^$" -benchmem
+func BenchmarkParseFormat(b *testing.B) {
+	for i := range linearBenchList {
+
+		e := &linearBenchList[i]
+
+		b.Run(e.name, func(bb *testing.B) {
+			for i := 0; i < bb.N; i++ {
+				_ = parseFormat(e.format)
+			}
+		})
+	}
+}
+
+// cache misses on every call
+// go test -bench "^BenchmarkSprintfCacheDisabled$" -run "^$" -benchmem
+func BenchmarkSprintfCacheDisabled(b *testing.B) {
+
+	defer SetCacheThreshold(CacheThreshold())
+
+	SetCacheThreshold(CacheDisabled)
+
+	for i := range linearBenchList {
+
+		e := &linearBenchList[i]
+
+		if e.typ != linearBenchTypeSprintf {
+			continue
+		}
+
+		b.Run(e.name, func(bb *testing.B) {
+			for i := 0; i < bb.N; i++ {
+				_ = Sprintf(e.format, e.args...)
+			}
+		})
+	}
+}
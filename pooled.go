@@ -0,0 +1,180 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// INFO: zero-copy results
+//      Sprint* fns always copy the pooled buffer into a fresh string. For request-scoped code, that formats into
+//      short-lived strings, the *Pooled versions return PooledString, which aliases the pooled buffer memory until
+//      explicit Release
+
+// PooledString is a result of *Pooled fns, it aliases the pooled buffer memory instead of copying it.
+// WARN String() result is valid only until Release call, after that its content may be silently overwritten,
+//      so it must not be retained (stored, used as a map key, sent to another goroutine, etc.)
+//      Use `string([]byte(s))` to get a copy for long-term use
+// NOTE copies of PooledString share the lease of the buffer, so the buffer is returned to the pool only once by the
+//      first Release of any copy, and all of the copies are empty after that
+type PooledString struct {
+	s     string  // result for cases without buffer (empty and raw const formats)
+	lease *lease  // nil if result is not backed by a buffer
+	gen   uintptr // generation of lease at the moment of result creation
+}
+
+// lease is the state shared by copies of PooledString, leases are pooled apart from buffers and reused, so stale
+// copies are told apart by generation, which is incremented by every release
+type lease struct {
+	buf *buffer
+	gen uintptr
+}
+
+var leasePool = sync.Pool{
+	New: func() interface{} {
+		return new(lease)
+	},
+}
+
+// inlined
+//go:nosplit
+func pooledBuffer(buf *buffer) PooledString {
+
+	l := leasePool.Get().(*lease)
+	l.buf = buf
+
+	return PooledString{lease: l, gen: atomic.LoadUintptr(&l.gen)}
+}
+
+// leased reports whether the buffer isn't released yet by any copy
+// inlined
+//go:nosplit
+func (p *PooledString) leased() bool {
+	return atomic.LoadUintptr(&p.lease.gen) == p.gen
+}
+
+// String returns the result without copying, result of released one is empty
+// inlined
+//go:nosplit
+func (p PooledString) String() string {
+
+	if p.lease != nil {
+
+		if !p.leased() {
+			return ""
+		}
+
+		return bytesString(p.lease.buf.Bytes())
+	}
+
+	return p.s
+}
+
+// inlined
+//go:nosplit
+func (p PooledString) Len() int {
+
+	if p.lease != nil {
+
+		if !p.leased() {
+			return 0
+		}
+
+		return p.lease.buf.Len()
+	}
+
+	return len(p.s)
+}
+
+// Release returns underlying buffer to the pool, it is safe to call Release more than once, even for copies
+// inlined
+//go:nosplit
+func (p *PooledString) Release() {
+
+	// only one of the copies wins the lease
+	if (p.lease != nil) && atomic.CompareAndSwapUintptr(&p.lease.gen, p.gen, p.gen+1) {
+
+		l := p.lease
+
+		l.buf.Free()
+		l.buf = nil
+
+		leasePool.Put(l)
+	}
+
+	p.lease = nil
+	p.s = ""
+}
+
+// SEE (*xfmt).Sprint()
+func (fmt *xfmt) SprintPooled(args []string) PooledString {
+
+	// fast-paths
+	// - format is empty string and no args
+	if (len(fmt.tokens) == 0) && (len(args) == 0) {
+		return PooledString{}
+	}
+
+	// - format is a single raw const string value without any verb
+	if (len(fmt.tokens) == 1) && (fmt.args == 0) && (len(args) == 0 /* implies `args == nil` */) &&
+		(fmt.tokens[0].verb == verbNone) {
+		return PooledString{s: fmt.tokens[0].value}
+	}
+
+	if b := fmt.bprint(args); b != nil {
+		return pooledBuffer(b)
+	}
+
+	// impossible situation
+	return PooledString{}
+}
+
+// SEE sprint()
+//go:nosplit
+func sprintPooled(ln bool, s ...string) PooledString {
+
+	if buf := bprint(ln, s...); buf != nil {
+		return pooledBuffer(buf)
+	}
+
+	if ln {
+		return PooledString{s: strLF}
+	}
+
+	return PooledString{}
+}
+
+// SprintPooled is like Sprint, but returns a result without copying; it must be released after use
+//go:nosplit
+func SprintPooled(s ...string) PooledString {
+	return sprintPooled(false, s...)
+}
+
+// SprintlnPooled is like Sprintln, but returns a result without copying; it must be released after use
+//go:nosplit
+func SprintlnPooled(s ...string) PooledString {
+	return sprintPooled(true, s...)
+}
+
+// SprintfPooled is like Sprintf, but returns a result without copying; it must be released after use
+func SprintfPooled(format string, args ...string) PooledString {
+	xfmt := forgeXfmt(format)
+	return xfmt.SprintPooled(args)
+}
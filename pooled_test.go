@@ -0,0 +1,142 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"testing"
+)
+
+// go test -count=1 -v -run "^TestSprintfPooled$"
+func TestSprintfPooled(t *testing.T) {
+
+	cases := [...]fmtSLTestCase{
+		{"", nil, ""},
+		{"raw const string", nil, "raw const string"},
+		{"%s", SL{tftc1arg13str}, tftc1arg13str},
+		{"%-10s|%q|% #x", SL{"abc", tftc1arg4utf8cjk, tftc1arg3short}, "abc       |\"攱枬貮\"|0x78 0x66 0x6d 0x74 0x2e"},
+		{"%s", SL{"x", "y"}, "x%!(EXTRA string=y)"},
+	}
+
+	for i := range cases {
+
+		tcase := &cases[i]
+
+		r := SprintfPooled(tcase.format, tcase.args...)
+
+		if got := r.String(); got != tcase.result {
+			t.Fatalf("%d: SprintfPooled(%q) mismatch: want <%s>, got <%s>", i, tcase.format, tcase.result, got)
+		}
+
+		if got := r.Len(); got != len(tcase.result) {
+			t.Fatalf("%d: SprintfPooled(%q) len mismatch: want %d, got %d", i, tcase.format, len(tcase.result), got)
+		}
+
+		r.Release()
+		// must be safe
+		r.Release()
+
+		if s := r.String(); s != "" {
+			t.Fatalf("%d: released result is not empty: %q", i, s)
+		}
+	}
+
+	assertMallocs(t, "SprintfPooled", 0, func() {
+		r := SprintfPooled("%s %q %x", tftc1arg13str, tftc1arg12str, tftc1arg3short)
+		_ = r.String()
+		r.Release()
+	})
+}
+
+// go test -count=1 -v -run "^TestSprintPooled$"
+func TestSprintPooled(t *testing.T) {
+
+	r := SprintPooled(testcaseSprintList...)
+
+	if want := Sprint(testcaseSprintList...); r.String() != want {
+		t.Fatalf("SprintPooled mismatch: want %q, got %q", want, r.String())
+	}
+
+	r.Release()
+
+	r = SprintlnPooled(testcaseSprintList...)
+
+	if want := Sprintln(testcaseSprintList...); r.String() != want {
+		t.Fatalf("SprintlnPooled mismatch: want %q, got %q", want, r.String())
+	}
+
+	r.Release()
+
+	if r = SprintlnPooled(); r.String() != strLF {
+		t.Fatalf("SprintlnPooled without args mismatch: got %q", r.String())
+	}
+}
+
+// go test -count=1 -v -run "^TestPooledStringCopies$"
+func TestPooledStringCopies(t *testing.T) {
+
+	r := SprintfPooled("%s %q", tftc1arg13str, tftc1arg12str)
+	c := r
+
+	r.Release()
+
+	// the buffer may be already reused by another result, copy must neither see nor release it
+	other := SprintfPooled("%s", tftc1arg13str)
+
+	if s := c.String(); s != "" {
+		t.Fatalf("released copy is not empty: %q", s)
+	}
+
+	if l := c.Len(); l != 0 {
+		t.Fatalf("released copy len is not zero: %d", l)
+	}
+
+	c.Release()
+
+	if got := other.String(); got != tftc1arg13str {
+		t.Fatalf("result is broken by release of copy: want <%s>, got <%s>", tftc1arg13str, got)
+	}
+
+	// the buffer must be returned to the pool once
+	next := SprintfPooled("%s", tftc1arg12str)
+
+	if other.lease.buf == next.lease.buf {
+		t.Fatal("leased buffer is reused")
+	}
+
+	other.Release()
+	next.Release()
+}
+
+// go test -bench "^BenchmarkSprintfPooled$" -run "^$" -benchmem
+func BenchmarkSprintfPooled(b *testing.B) {
+
+	b.Run("pooled", func(bb *testing.B) {
+		for i := 0; i < bb.N; i++ {
+			r := SprintfPooled("%s %q", tftc1arg13str, tftc1arg12str)
+			_ = r.String()
+			r.Release()
+		}
+	})
+
+	b.Run("copy", func(bb *testing.B) {
+		for i := 0; i < bb.N; i++ {
+			_ = Sprintf("%s %q", tftc1arg13str, tftc1arg12str)
+		}
+	})
+}
@@ -96,6 +96,11 @@ func sprint(ln bool, s ...string) (r string) {
 //go:nosplit
 func fprint(w io.Writer, ln bool, s ...string) (n int, err error) {
 
+	// large result should be streamed instead of whole-message buffering
+	if sumLen(s)+len(s) > streamThreshold {
+		return fstream(w, ln, s...)
+	}
+
 	buf := bprint(ln, s...)
 
 	if buf == nil {
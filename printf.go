@@ -28,10 +28,37 @@ import (
 //      hint: use implicit `pxfmt := new(xfmt)` in the right place (and next `*pxfmt = xfmt`) to avoid explicit unwanted one
 //      in wrong place
 
+// frontend is the syntax of formats (printf-like or brace one), all frontends produce the same tokens and share the
+// same caches, but the same format string means different things for different frontends, so cache keys are tagged
+// by frontend syntax
+type frontend struct {
+	syntax syntax
+	parse  func(format string) xfmt
+}
+
+var printfFrontend = frontend{
+	syntax: syntaxPrintf,
+	parse:  parseFormat,
+}
+
 // NOTE xfmt is for now by value
 func forgeXfmt(format string) (xfmt xfmt) {
+	return printfFrontend.forge(format, false)
+}
+
+// forgeXfmtFrom is forgeXfmt for format, which is possibly `transient`, i.e. shares memory that may be modified
+// after the call (e.g. []byte format), so such format is copied before it's stored in any of caches (tokens of
+// cached xfmt reference format's memory)
+func forgeXfmtFrom(format string, transient bool) (xfmt xfmt) {
+	return printfFrontend.forge(format, transient)
+}
+
+// SEE forgeXfmtFrom()
+func (fe *frontend) forge(format string, transient bool) (xfmt xfmt) {
+
+	key := formatKey{format: format, syntax: fe.syntax}
 
-	xfmt, has := xfmtCache.Get(format)
+	xfmt, has := xfmtCache.Get(key)
 
 	threshold := CacheThreshold()
 
@@ -40,27 +67,45 @@ func forgeXfmt(format string) (xfmt xfmt) {
 		return xfmt
 	}
 
-	// not in cache, should parse and cache if needed
-	xfmt = parseFormat(format)
+	shouldCache := false
 
 	if threshold != CacheDisabled {
 
-		shouldCache := threshold == CacheAlways
+		shouldCache = threshold == CacheAlways
 
 		// use counters cache only if need it
 		if !shouldCache {
-			shouldCache = countersCache.Count(format) > threshold
+			shouldCache = countersCache.Count(key, transient) > threshold
 		}
+	}
 
-		if shouldCache {
-			// store in cache...
-			xfmtCache.Set(format, xfmt)
+	if !shouldCache {
+		// not in cache and should not be cached, tokens may reference even transient format during the call
+		return fe.parse(format)
+	}
 
-			// ...and then remove format value from counters cache if needed to reduce counters heapsize and memallocs
-			if threshold != CacheAlways {
-				countersCache.Delete(format)
-			}
-		}
+	// must be loaded before parsing, SEE formatCache
+	version := xfmtCache.Version()
+
+	// tokens of cached xfmt must not reference transient format memory
+	if transient {
+		format = string(stringBytes(format))
+		key.format = format
+	}
+
+	// not in cache, should parse and cache
+	xfmt = fe.parse(format)
+
+	// cached formats are used many times, so it is worth to shrink and compile them
+	xfmt.shrink()
+	xfmt.chain = xfmt.compile()
+
+	// store in cache...
+	xfmtCache.Set(key, xfmt, version)
+
+	// ...and then remove format value from counters cache if needed to reduce counters heapsize and memallocs
+	if threshold != CacheAlways {
+		countersCache.Delete(key)
 	}
 
 	return xfmt
@@ -57,6 +57,17 @@ func TestPrinterSprintf(t *testing.T) {
 	}
 }
 
+// `%v` of string args is the same as `%s` like in std `fmt` (it was the bad verb before typed args)
+// go test -count=1 -v -run "^TestSprintfValueVerb$"
+func TestSprintfValueVerb(t *testing.T) {
+
+	for _, format := range [...]string{"%v", "%10v|", "%-5v|", "%.2v", "%#v", "%+v", "%x%v", "%[2]v %[1]v"} {
+		if got, want := Sprintf(format, "abc", "d"), fmt.Sprintf(hideFromVet(format), "abc", "d"); got != want {
+			t.Errorf("%q: mismatch result: want <%s>, got <%s>", format, want, got)
+		}
+	}
+}
+
 // benchmarks
 // go test -bench "^BenchmarkLinearXfmtOnly$" -run "^$" -benchmem
 // go test -bench "^BenchmarkLinearXfmtOnly$" -run "^$" -benchmem -cpuprofile cpu.pprof -memprofile mem.pprof
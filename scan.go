@@ -0,0 +1,378 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// INFO: scanning
+//      Sscanf and Match are reverse of Sprintf for string verbs: the same cached parsed format is matched against the
+//      input. Raw const string values must be equal to the input, `%s` takes either the input up to the next raw const
+//      string value or (if the verb is followed by another verb or is the last one) up to the whitespace, `%q` takes
+//      quoted string and unquotes it, `%x` and `%X` take hex digits (with "0x" for `%#x` and separated by spaces for
+//      `% x`) and decode them up to the next raw const string value. Verbs with width take exactly `width` runes and
+//      trim their padding spaces, so padded results of Sprintf are scanned back. Precision limits scanned value the
+//      same way as it truncates the arg of Sprintf: `%.3s` takes at most 3 runes, `%.3x` decodes at most 3 bytes,
+//      longer values of fields delimited by width, quotes or raw const string value don't match the format
+
+var (
+	ErrScanMismatch = errors.New("xfmt: input does not match format")
+	ErrScanEOF      = errors.New("xfmt: unexpected EOF")
+	ErrScanVerb     = errors.New("xfmt: verb can't be scanned")
+	ErrScanFewArgs  = errors.New("xfmt: too few operands for format")
+	ErrScanManyArgs = errors.New("xfmt: too many operands")
+	ErrScanQuoted   = errors.New("xfmt: bad quoted string")
+	ErrScanHex      = errors.New("xfmt: bad hex string")
+)
+
+const (
+	spaceString     = string(charSpace)
+	whitespaceChars = " \t\n\r\v\f"
+)
+
+// max amount of scanned values kept on stack
+const scanArgsStackSize = 16
+
+// scan matches input against format storing scanned values of verbs into `values` (by arg indexes), returns the
+// amount of scanned verbs and not consumed tail of input
+func (fmt *xfmt) scan(input string, values []string) (n int, rest string, err error) {
+
+	for i := 0; i < len(fmt.tokens); i++ {
+
+		token := &fmt.tokens[i]
+
+		// raw const string value (including error marks of malformed format) must be equal to the input
+		if token.verb == verbNone {
+
+			if !strings.HasPrefix(input, token.value) {
+				if len(input) < len(token.value) && strings.HasPrefix(token.value, input) {
+					return n, input, ErrScanEOF
+				}
+
+				return n, input, ErrScanMismatch
+			}
+
+			input = input[len(token.value):]
+
+			continue
+		}
+
+		if token.flags.has(flagIndirectWidth|flagIndirectPrec) || (token.verb > verbValue) {
+			return n, input, ErrScanVerb
+		}
+
+		if uint(token.arg) >= uint(len(values)) {
+			return n, input, ErrScanFewArgs
+		}
+
+		// the next raw const string value delimits `%s`
+		delim := ""
+
+		if (i+1 < len(fmt.tokens)) && (fmt.tokens[i+1].verb == verbNone) {
+			delim = fmt.tokens[i+1].value
+		}
+
+		var value string
+
+		if value, input, err = token.scan(input, delim); err != nil {
+			return n, input, err
+		}
+
+		values[token.arg] = value
+
+		n++
+	}
+
+	return n, input, nil
+}
+
+// scan scans value of the verb from input
+func (token *token) scan(input, delim string) (value, rest string, err error) {
+
+	field, rest := input, ""
+
+	if width := int(token.width); width > 0 {
+
+		// fixed width field
+		field = truncateTail(input, width)
+		rest = input[len(field):]
+
+		// padding spaces are either on the left or on the right
+		if token.flags.has(flagPadRight) {
+			field = strings.TrimRight(field, spaceString)
+		} else {
+			field = strings.TrimLeft(field, spaceString)
+		}
+	}
+
+	prec := int(token.prec) // negative if there is no precision
+
+	switch token.verb {
+	case verbString, verbValue:
+		if token.width > 0 {
+
+			if (prec >= 0) && (utf8.RuneCountInString(field) > prec) {
+				return "", input, ErrScanMismatch
+			}
+
+			return field, rest, nil
+		}
+
+		end := -1
+
+		if delim != "" {
+			end = strings.Index(input, delim)
+		} else {
+			end = strings.IndexAny(input, whitespaceChars)
+		}
+
+		if end == -1 {
+			// literal delimiter must exist, but whitespace delimited value may be the tail
+			if delim != "" {
+				return "", input, ErrScanEOF
+			}
+
+			end = len(input)
+		}
+
+		value = input[:end]
+
+		// the rest of too long value must match the next token
+		if prec >= 0 {
+			value = truncateTail(value, prec)
+		}
+
+		return value, input[len(value):], nil
+
+	case verbQuoted:
+		q, err := strconv.QuotedPrefix(field)
+
+		if (err != nil) || ((token.width > 0) && (len(q) != len(field))) {
+			return "", input, ErrScanQuoted
+		}
+
+		if value, err = strconv.Unquote(q); err != nil {
+			return "", input, ErrScanQuoted
+		}
+
+		if (prec >= 0) && (utf8.RuneCountInString(value) > prec) {
+			return "", input, ErrScanMismatch
+		}
+
+		if token.width <= 0 {
+			rest = field[len(q):]
+		}
+
+		return value, rest, nil
+	}
+
+	// verbHex
+	if (token.width <= 0) && (delim != "") {
+		return token.scanHexDelim(input, delim)
+	}
+
+	value, n, ok := unhexPrefix(field, token.flags, prec)
+
+	if !ok || ((token.width > 0) && (n != len(field))) {
+		return "", input, ErrScanHex
+	}
+
+	if token.width <= 0 {
+		rest = field[n:]
+	}
+
+	return value, rest, nil
+}
+
+// scanHexDelim scans hex value delimited by the next raw const string value, which may start with hex digits too
+// ("%xcd"), so the shortest field followed by the delimiter and consisting only of hex encoded bytes is taken
+func (token *token) scanHexDelim(input, delim string) (value, rest string, err error) {
+
+	prec := int(token.prec)
+
+	off := 0
+
+	for off <= len(input) {
+
+		end := strings.Index(input[off:], delim)
+
+		if end == -1 {
+			break
+		}
+
+		end += off
+
+		field := input[:end]
+
+		v, n, ok := unhexPrefix(field, token.flags, -1)
+
+		if ok && (n == len(field)) {
+
+			if (prec >= 0) && (len(v) > prec) {
+				return "", input, ErrScanMismatch
+			}
+
+			return v, input[end:], nil
+		}
+
+		off = end + 1
+	}
+
+	// literal delimiter must exist, SEE verbString
+	if off == 0 {
+		return "", input, ErrScanEOF
+	}
+
+	return "", input, ErrScanHex
+}
+
+// unhexPrefix decodes the longest prefix of s with hex encoded bytes (at most max bytes if max isn't negative) and
+// returns its len; `%#x` prefix is "0x" (or "0X") and every element of `% #x` has this prefix, elements of `% x` are
+// separated by spaces
+//go:nosplit
+func unhexPrefix(s string, flags flags, max int) (value string, n int, ok bool) {
+
+	withSpace, altFmt := flags.has(flagWithSpace), flags.has(flagAltFmt)
+
+	var b []byte
+
+	for (n < len(s)) && ((max < 0) || (len(b) < max)) {
+
+		i := n
+
+		if (i > 0) && withSpace {
+			// separator
+			if s[i] != charSpace {
+				break
+			}
+
+			i++
+		}
+
+		// prefix is the same for every element of spaced form and is the only one for the whole string otherwise
+		if altFmt && ((i == 0) || withSpace) {
+
+			if (i+1 >= len(s)) || (s[i] != charZero) || ((s[i+1] | ('a' - 'A')) != 'x') {
+				break
+			}
+
+			i += 2
+		}
+
+		if i+1 >= len(s) {
+			break
+		}
+
+		hi, lo := unhexDigit(s[i]), unhexDigit(s[i+1])
+
+		if (hi > 0x0F) || (lo > 0x0F) {
+			break
+		}
+
+		b = append(b, hi<<4|lo)
+
+		n = i + 2
+	}
+
+	// empty value is proper only for empty input
+	if (len(b) == 0) && (n != len(s)) {
+		return "", 0, false
+	}
+
+	return bytesString(b), n, true
+}
+
+// unhexDigit returns value of hex digit c or 0xFF for non-hex-digit c
+// inlined
+//go:nosplit
+func unhexDigit(c byte) byte {
+
+	switch {
+	case ('0' <= c) && (c <= '9'):
+		return c - '0'
+	case ('a' <= c) && (c <= 'f'):
+		return c - 'a' + 10
+	case ('A' <= c) && (c <= 'F'):
+		return c - 'A' + 10
+	}
+
+	return 0xFF
+}
+
+// Sscanf scans input according to format storing values of verbs into args and returns the amount of scanned
+// values, SEE the INFO above
+func Sscanf(input, format string, args ...*string) (n int, err error) {
+
+	xfmt := forgeXfmt(format)
+
+	var scratch [scanArgsStackSize]string
+
+	values := scratch[:0]
+
+	if uint(len(args)) > uint(len(scratch)) {
+		values = make([]string, 0, len(args))
+	}
+
+	// args not scanned due to error keep their values
+	for i := 0; i < len(args); i++ {
+
+		var v string
+
+		if args[i] != nil {
+			v = *args[i]
+		}
+
+		values = append(values, v)
+	}
+
+	n, _, err = xfmt.scan(input, values)
+
+	// like in std `fmt`, values scanned before the error are stored
+	for i := 0; i < len(args); i++ {
+		if args[i] != nil {
+			*args[i] = values[i]
+		}
+	}
+
+	if (err == nil) && (uint(len(args)) > xfmt.args) {
+		err = ErrScanManyArgs
+	}
+
+	return n, err
+}
+
+// Match matches the whole input against format and returns values of the args used by format (by arg indexes)
+func Match(format, input string) ([]string, bool) {
+
+	xfmt := forgeXfmt(format)
+
+	values := make([]string, xfmt.args)
+
+	_, rest, err := xfmt.scan(input, values)
+
+	if (err != nil) || (rest != "") {
+		return nil, false
+	}
+
+	return values, true
+}
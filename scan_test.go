@@ -0,0 +1,191 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"testing"
+)
+
+// go test -count=1 -v -run "^TestSscanfRoundTrip$"
+func TestSscanfRoundTrip(t *testing.T) {
+
+	formats := [...]string{
+		"user=%q id=%s",
+		"%x|%X|%#x|% x|% #X",
+		"[%10s][%-10s][%12q][%-14x]",
+		"%[2]s-%[1]s",
+		"%s:%s",
+		"%q%q",
+	}
+
+	values := [...]string{"", "bob", "Привет", "with space", `quo"te`, "\x00\xff"}
+
+	for _, format := range formats {
+		for _, v := range values {
+
+			xfmt := forgeXfmt(format)
+
+			args := make([]string, xfmt.args)
+
+			for i := range args {
+				args[i] = v
+			}
+
+			s := Sprintf(format, args...)
+
+			got, ok := Match(format, s)
+
+			// values with spaces and delimiters can't be scanned back by `%s` and `% x` by design
+			if !ok {
+				continue
+			}
+
+			for i := range got {
+				if got[i] != v {
+					t.Errorf("%q %q: mismatch arg %d: want <%s>, got <%s>", format, s, i, v, got[i])
+				}
+			}
+		}
+	}
+
+	// these ones must always be scanned back
+	for _, v := range values {
+
+		var q, x string
+
+		s := Sprintf("q=%q x=%x", v, v)
+
+		if n, err := Sscanf(s, "q=%q x=%x", &q, &x); (err != nil) || (n != 2) || (q != v) || (x != v) {
+			t.Errorf("%q: mismatch result: %d %v <%s> <%s>", s, n, err, q, x)
+		}
+	}
+}
+
+// go test -count=1 -v -run "^TestSscanf$"
+func TestSscanf(t *testing.T) {
+
+	var user, id string
+
+	n, err := Sscanf(`user="bob" id=42`, "user=%q id=%s", &user, &id)
+
+	if (err != nil) || (n != 2) || (user != "bob") || (id != "42") {
+		t.Errorf("mismatch result: %d %v <%s> <%s>", n, err, user, id)
+	}
+
+	// values scanned before error are stored, others keep their values
+	user, id = "", "keep"
+
+	if n, err = Sscanf(`user="bob" ID=42`, "user=%q id=%s", &user, &id); (err != ErrScanMismatch) || (n != 1) ||
+		(user != "bob") || (id != "keep") {
+		t.Errorf("mismatch result: %d %v <%s> <%s>", n, err, user, id)
+	}
+
+	cases := [...]struct {
+		input  string
+		format string
+		args   int
+		err    error
+	}{
+		{"a b", "%s %s", 1, ErrScanFewArgs},
+		{"a", "%s", 2, ErrScanManyArgs},
+		{"a=", "a=%d", 1, ErrScanVerb},
+		{"a=", "a=%*s", 2, ErrScanVerb},
+		{"ab", "abc", 0, ErrScanEOF},
+		{"abc", "%sd", 1, ErrScanEOF},
+		{"ab", "ac", 0, ErrScanMismatch},
+		{`"ab`, "%q", 1, ErrScanQuoted},
+		{"zz", "%x", 1, ErrScanHex},
+		{"  6", "%3x", 1, ErrScanHex},
+	}
+
+	for _, c := range cases {
+
+		args := make([]*string, c.args)
+
+		for i := range args {
+			args[i] = new(string)
+		}
+
+		if _, err := Sscanf(c.input, c.format, args...); err != c.err {
+			t.Errorf("%q %q: mismatch error: want %v, got %v", c.input, c.format, c.err, err)
+		}
+	}
+
+	// `%s` values are substrings of the input
+	assertMallocs(t, "Sscanf", 0, func() {
+		_, _ = Sscanf("user=bob id=42", "user=%s id=%s", &user, &id)
+	})
+}
+
+// go test -count=1 -v -run "^TestMatch$"
+func TestMatch(t *testing.T) {
+
+	cases := [...]struct {
+		format string
+		input  string
+		want   []string
+	}{
+		{"", "", []string{}},
+		{"[%10s][%-6s]", "[       bob][ab    ]", []string{"bob", "ab"}},
+		{"%s %s", "a b", []string{"a", "b"}},
+		{"%s=%s", "k=v=w", []string{"k", "v=w"}},
+		{"%#x|% x|% #X", "0x6162|61 62|0X61 0X62", []string{"ab", "ab", "ab"}},
+		{"%[2]s-%[1]s", "b-a", []string{"a", "b"}},
+		{"a%sb", "a", nil},
+		{"%s", "a b", nil},
+
+		// hex value stops before the delimiter, even if it starts with hex digits
+		{"%xcd", "abcd", []string{"\xab"}},
+		{"%x|%xab", "61|6162ab", []string{"a", "ab"}},
+		{"% #xff", "0x61 0x62ff", []string{"ab"}},
+		{"%xcd", "abc", nil},
+
+		// precision limits values like it truncates args
+		{"%.3s%s", "abcdef", []string{"abc", "def"}},
+		{"%.3s|", "ab|", []string{"ab"}},
+		{"%.3s|", "abcd|", nil},
+		{"%5.2s|", "   ab|", []string{"ab"}},
+		{"%5.2s|", "  abc|", nil},
+		{"%.1q", `"a"`, []string{"a"}},
+		{"%.1q", `"ab"`, nil},
+		{"%.2x%s", "616263", []string{"ab", "63"}},
+		{"%.1x|", "6162|", nil},
+	}
+
+	for _, c := range cases {
+
+		got, ok := Match(c.format, c.input)
+
+		if ok != (c.want != nil) {
+			t.Errorf("%q %q: unexpected match status %v", c.format, c.input, ok)
+			continue
+		}
+
+		if len(got) != len(c.want) {
+			t.Errorf("%q %q: mismatch result: want %q, got %q", c.format, c.input, c.want, got)
+			continue
+		}
+
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("%q %q: mismatch result: want %q, got %q", c.format, c.input, c.want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,259 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import "strconv"
+
+// INFO: format signatures
+//      Signature of the format is the list of uses of every arg (positional args by index, named placeholders by
+//      name), each use is the verb with its flags or indirect width or precision (`*`). Comparing signatures of
+//      source format and its translation reveals mistakes of reordering (`%[2]s`) before they show up as
+//      `%!s(MISSING)` at runtime
+
+// SignatureMismatch is the difference between signatures of source and translated formats
+type SignatureMismatch struct {
+	Arg    int    // 1-based arg number (as in `%[n]s`), 0 for named placeholders and for the whole format
+	Name   string // name of named placeholder (`%{name}s`)
+	Reason string
+}
+
+func (m *SignatureMismatch) Error() string {
+	return "xfmt: format signature mismatch" + m.message()
+}
+
+func (m *SignatureMismatch) message() string {
+
+	switch {
+	case m.Arg > 0:
+		return " of arg " + strconv.Itoa(m.Arg) + ": " + m.Reason
+	case m.Name != "":
+		return " of arg {" + m.Name + "}: " + m.Reason
+	}
+
+	return ": " + m.Reason
+}
+
+// reasons of SignatureMismatch
+const (
+	sigReasonArgsCount  = "different args count"
+	sigReasonUnused     = "arg is not used"
+	sigReasonUnknown    = "arg is not used by source"
+	sigReasonDuplicated = "arg is used more times than in source"
+	sigReasonVerb       = "different verb"
+	sigReasonFlags      = "different flags"
+)
+
+// argUse is a single use of the arg, verbNone means indirect width or precision
+type argUse struct {
+	verb  verb
+	flags flags // only flags, which affect the result, including flagUpperVerb
+}
+
+// flags of argUse
+const sigFlagsMask = flagPlus | flagMinus | flagSharp | flagSpace | flagZero | flagUpperVerb
+
+type signature struct {
+	args  uint
+	uses  [][]argUse // positional args uses by arg index
+	names []string
+	named [][]argUse // named placeholders uses, parallel to names
+}
+
+// inlined
+//go:nosplit
+func (sig *signature) use(idx uint32, use argUse) {
+
+	if idx >= namedArgBase {
+		sig.named[idx-namedArgBase] = append(sig.named[idx-namedArgBase], use)
+		return
+	}
+
+	// NOTE parser guarantees that arg indexes are less than xfmt.args, so it's just a sanity check
+	for uint32(len(sig.uses)) <= idx {
+		sig.uses = append(sig.uses, nil)
+	}
+
+	sig.uses[idx] = append(sig.uses[idx], use)
+}
+
+func makeSignature(format string) (sig signature) {
+
+	fmt := parseFormat(format)
+
+	sig = signature{
+		args:  fmt.args,
+		uses:  make([][]argUse, fmt.args),
+		names: fmt.names,
+		named: make([][]argUse, len(fmt.names)),
+	}
+
+	for i := 0; i < len(fmt.tokens); i++ {
+
+		token := &fmt.tokens[i]
+
+		// raw const string values, errors of bad arg nums and conditional groups (their tokens follow them)
+		if (token.verb == verbNone) || (token.verb == verbGroup) {
+			continue
+		}
+
+		if token.flags.has(flagIndirectWidth) {
+			sig.use(uint32(token.width), argUse{})
+		}
+
+		if token.flags.has(flagIndirectPrec) {
+			sig.use(uint32(token.prec), argUse{})
+		}
+
+		sig.use(token.arg, argUse{verb: token.verb, flags: token.flags & sigFlagsMask})
+	}
+
+	return sig
+}
+
+// nameUses returns uses of named placeholder name, nil if there are no ones
+//go:nosplit
+func (sig *signature) nameUses(name string) []argUse {
+
+	for i := 0; i < len(sig.names); i++ {
+		if sig.names[i] == name {
+			return sig.named[i]
+		}
+	}
+
+	return nil
+}
+
+// CompareSignatures compares args usage of source format and its translation: args count, unused, unknown and
+// duplicated args, verbs and flags of every arg; returns nil if signatures match
+// NOTE every use of the arg by translated format must have the use by source one with the same verb and flags
+func CompareSignatures(source, translated string) []SignatureMismatch {
+	return compareSignatures(makeSignature(source), makeSignature(translated))
+}
+
+func compareSignatures(src, tr signature) (mismatches []SignatureMismatch) {
+
+	if src.args != tr.args {
+		mismatches = append(mismatches, SignatureMismatch{Reason: sigReasonArgsCount})
+	}
+
+	n := len(src.uses)
+
+	if len(tr.uses) > n {
+		n = len(tr.uses)
+	}
+
+	for i := 0; i < n; i++ {
+
+		var s, t []argUse
+
+		if i < len(src.uses) {
+			s = src.uses[i]
+		}
+
+		if i < len(tr.uses) {
+			t = tr.uses[i]
+		}
+
+		mismatches = compareUses(mismatches, SignatureMismatch{Arg: i + 1}, s, t)
+	}
+
+	for i, name := range src.names {
+		mismatches = compareUses(mismatches, SignatureMismatch{Name: name}, src.named[i], tr.nameUses(name))
+	}
+
+	for i, name := range tr.names {
+		if src.nameUses(name) == nil {
+			mismatches = compareUses(mismatches, SignatureMismatch{Name: name}, nil, tr.named[i])
+		}
+	}
+
+	return mismatches
+}
+
+// compareUses appends mismatches between source uses s and translated uses t of the arg
+func compareUses(mismatches []SignatureMismatch, arg SignatureMismatch, s, t []argUse) []SignatureMismatch {
+
+	mismatch := func(reason string) {
+		arg.Reason = reason
+		mismatches = append(mismatches, arg)
+	}
+
+	switch {
+	case (len(t) == 0) && (len(s) != 0):
+		mismatch(sigReasonUnused)
+	case (len(s) == 0) && (len(t) != 0):
+		mismatch(sigReasonUnknown)
+		return mismatches
+	case len(t) > len(s):
+		mismatch(sigReasonDuplicated)
+	}
+
+	var badVerb, badFlags bool
+
+	for _, u := range t {
+
+		verbFound, flagsFound := false, false
+
+		for _, v := range s {
+			if (u.verb == v.verb) && ((u.flags & flagUpperVerb) == (v.flags & flagUpperVerb)) {
+				verbFound = true
+				flagsFound = flagsFound || (u.flags == v.flags)
+			}
+		}
+
+		badVerb = badVerb || !verbFound
+		badFlags = badFlags || (verbFound && !flagsFound)
+	}
+
+	if badVerb {
+		mismatch(sigReasonVerb)
+	}
+
+	if badFlags {
+		mismatch(sigReasonFlags)
+	}
+
+	return mismatches
+}
+
+// hasDirectives reports whether the source format has any args
+// inlined
+//go:nosplit
+func (sig *signature) hasDirectives() bool {
+	return (sig.args != 0) || (len(sig.names) != 0)
+}
+
+// checkTranslation returns the reason of CatalogError if translated format of msgid doesn't match it, msgids
+// without any directives are considered as symbolic keys and aren't checked
+func checkTranslation(msgid, translated string) string {
+
+	src := makeSignature(msgid)
+
+	if !src.hasDirectives() {
+		return ""
+	}
+
+	mismatches := compareSignatures(src, makeSignature(translated))
+
+	if len(mismatches) == 0 {
+		return ""
+	}
+
+	return "translation of " + strconv.Quote(msgid) + " doesn't match it" + mismatches[0].message()
+}
@@ -0,0 +1,130 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// go test -count=1 -v -run "^TestCompareSignatures$"
+func TestCompareSignatures(t *testing.T) {
+
+	type m = SignatureMismatch
+
+	cases := [...]struct {
+		source, translated string
+		want               []SignatureMismatch
+	}{
+		{"user %s from %s", "пользователь %s с адреса %s", nil},
+		{"user %s from %s", "с адреса %[2]s пользователь %[1]s", nil},
+		{"%s: %[1]q", "%[1]q: %[1]s", nil},
+		{"%*s|%-8x|%.*q", "%-8[3]x|%.[4]*[5]q|%[1]*[2]s", nil},
+		{"%{user}s at %{host}q", "%{host}q: %{user}s", nil},
+		{"", "", nil},
+		{"user %s from %s", "пользователь %s", []SignatureMismatch{
+			m{Reason: sigReasonArgsCount}, m{Arg: 2, Reason: sigReasonUnused},
+		}},
+		{"user %s", "пользователь %[2]s", []SignatureMismatch{
+			m{Reason: sigReasonArgsCount}, m{Arg: 1, Reason: sigReasonUnused}, m{Arg: 2, Reason: sigReasonUnknown},
+		}},
+		{"user %s from %s", "%[1]s %[1]s", []SignatureMismatch{
+			m{Reason: sigReasonArgsCount}, m{Arg: 1, Reason: sigReasonDuplicated}, m{Arg: 2, Reason: sigReasonUnused},
+		}},
+		{"user %s", "пользователь %q", []SignatureMismatch{m{Arg: 1, Reason: sigReasonVerb}}},
+		{"hash %x", "хеш %X", []SignatureMismatch{m{Arg: 1, Reason: sigReasonVerb}}},
+		{"hash %x", "хеш %#x", []SignatureMismatch{m{Arg: 1, Reason: sigReasonFlags}}},
+		{"user %10s", "пользователь %-10s", []SignatureMismatch{m{Arg: 1, Reason: sigReasonFlags}}},
+		{"%*s", "%[2]s", []SignatureMismatch{m{Arg: 1, Reason: sigReasonUnused}}},
+		{"%{user}s", "%{usr}s", []SignatureMismatch{
+			m{Name: "user", Reason: sigReasonUnused}, m{Name: "usr", Reason: sigReasonUnknown},
+		}},
+		{"%{user}s", "%{user}s %{user}d", []SignatureMismatch{
+			m{Name: "user", Reason: sigReasonDuplicated}, m{Name: "user", Reason: sigReasonVerb},
+		}},
+	}
+
+	for _, c := range cases {
+		if got := CompareSignatures(c.source, c.translated); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q vs %q: mismatch result: want %v, got %v", c.source, c.translated, c.want, got)
+		}
+	}
+
+	mismatches := CompareSignatures("%s %s", "%[3]s")
+
+	want := []string{
+		"xfmt: format signature mismatch: " + sigReasonArgsCount,
+		"xfmt: format signature mismatch of arg 1: " + sigReasonUnused,
+		"xfmt: format signature mismatch of arg 2: " + sigReasonUnused,
+		"xfmt: format signature mismatch of arg 3: " + sigReasonUnknown,
+	}
+
+	if len(mismatches) != len(want) {
+		t.Fatalf("mismatch result: want %d mismatches, got %v", len(want), mismatches)
+	}
+
+	for i := range want {
+		if got := mismatches[i].Error(); got != want[i] {
+			t.Errorf("Error() mismatch result: want <%s>, got <%s>", want[i], got)
+		}
+	}
+}
+
+// go test -count=1 -v -run "^TestCatalogSignatures$"
+func TestCatalogSignatures(t *testing.T) {
+
+	const po = `msgid "Hello, %s!"
+msgstr "Привет, %s!"
+
+msgid "user %s from %s"
+msgstr "пользователь %[2]s с адреса %[2]s"
+`
+
+	c := NewCatalog()
+
+	err := c.LoadPO("ru", strings.NewReader(po))
+
+	var cerr *CatalogError
+
+	if !errors.As(err, &cerr) {
+		t.Fatalf("LoadPO: unexpected error: %v", err)
+	}
+
+	if want := `translation of "user %s from %s" doesn't match it of arg 1: ` + sigReasonUnused; (cerr.Line != 4) ||
+		(cerr.Reason != want) {
+		t.Errorf("LoadPO: mismatch error: want 4: %s, got %d: %s", want, cerr.Line, cerr.Reason)
+	}
+
+	if c.Has("ru", "Hello, %s!") {
+		t.Errorf("LoadPO: messages are loaded despite the error")
+	}
+
+	// symbolic keys aren't checked
+	if err := c.LoadJSON(strings.NewReader(`{"ru": {"greeting": "Привет, %s!"}}`)); err != nil {
+		t.Errorf("LoadJSON: unexpected error: %v", err)
+	}
+
+	err = c.LoadJSON(strings.NewReader(`{"ru": {"hash %x": "хеш %q"}}`))
+
+	if !errors.As(err, &cerr) || (cerr.Reason != `ru: translation of "hash %x" doesn't match it of arg 1: `+sigReasonVerb) {
+		t.Errorf("LoadJSON: unexpected error: %v", err)
+	}
+}
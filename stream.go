@@ -0,0 +1,315 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"io"
+)
+
+// INFO: streaming mode for large results
+//      Common Fprint* path materializes the whole result in a pooled buffer and then does a single w.Write, which
+//      for multi-megabyte args doubles memory usage, moreover buffers above `maxAllowedBufSize` are thrown away
+//      instead of returning to the pool. Streaming mode keeps the buffer small by flushing it to the writer by chunks
+//      and writes large raw const values and large `%s` args directly to the writer (if it is io.StringWriter)
+
+const (
+	// estimated size of the result above which Fprint* fns switch to the streaming mode
+	streamThreshold = maxAllowedBufSize
+	// buffered data of at least this size is flushed to the writer, so buffer stays reusable by pool
+	streamFlushThreshold = maxAllowedBufSize >> 1 // 8k
+	// strings of at least this size are written directly to the writer (if it is io.StringWriter)
+	streamDirectThreshold = 1 << 10 // 1k
+	// max number of source bytes encoded in a single step of chunked hex encoding
+	streamHexChunkSize = streamFlushThreshold >> 3 // 1k ==> up to 5k of `% #x` output
+)
+
+type streamer struct {
+	w   io.Writer
+	sw  io.StringWriter // nil if `w` doesn't implement io.StringWriter
+	buf *buffer
+	n   int   // bytes written
+	err error // first write error, stops any further writes
+}
+
+// inlined
+//go:nosplit
+func newStreamer(w io.Writer, p *bufferpool) (s streamer) {
+
+	s.w, s.buf = w, p.Get()
+	s.sw, _ = w.(io.StringWriter)
+
+	return s
+}
+
+// should be called only once at the end of streaming
+//go:nosplit
+func (s *streamer) finish() (n int, err error) {
+
+	s.flush()
+
+	s.buf.Free()
+	s.buf = nil
+
+	return s.n, s.err
+}
+
+//go:nosplit
+func (s *streamer) flush() {
+
+	if (s.err != nil) || (s.buf.Len() == 0) {
+		return
+	}
+
+	n, err := s.w.Write(s.buf.Bytes())
+
+	// regardless of err count the number of bytes written
+	s.n += n
+	s.err = err
+
+	s.buf.Clear()
+}
+
+// inlined
+//go:nosplit
+func (s *streamer) flushIfFull() {
+	if s.buf.Len() >= streamFlushThreshold {
+		s.flush()
+	}
+}
+
+//go:nosplit
+func (s *streamer) WriteString(str string) {
+
+	if s.err != nil {
+		return
+	}
+
+	// small strings are always buffered
+	if len(str) < streamDirectThreshold {
+		s.buf.WriteString(str)
+		s.flushIfFull()
+		return
+	}
+
+	if s.sw != nil {
+
+		// WARN keep order - already buffered data goes first
+		if s.flush(); s.err != nil {
+			return
+		}
+
+		n, err := s.sw.WriteString(str)
+
+		s.n += n
+		s.err = err
+
+		return
+	}
+
+	// avoid string->[]byte memalloc, so copy large string through the buffer by chunks
+	for (str != "") && (s.err == nil) {
+
+		// NOTE m <= 0 means buffer is already full, so only flush below is needed
+		if m := streamFlushThreshold - s.buf.Len(); m > 0 {
+
+			if m > len(str) {
+				m = len(str)
+			}
+
+			s.buf.WriteString(str[:m])
+
+			str = str[m:]
+		}
+
+		s.flushIfFull()
+	}
+}
+
+// SEE (*token).format()
+//go:nosplit
+func (s *streamer) token(token *token, args []string) {
+
+	if token.verb == verbNone {
+		s.WriteString(token.value)
+		return
+	}
+
+	// erroneous and indirect cases are always small, so process them by common buffered path
+	if !token.flags.has(flagIndirectWidth|flagIndirectPrec) && (uint(token.arg) < uint(len(args))) {
+
+		switch arg := token.withDefault(args[token.arg]); token.verb {
+		case verbString:
+			s.fmtStr(arg, token.flags, int(token.width), int(token.prec))
+			return
+		case verbValue:
+			// SEE fmtValue()
+			if !token.flags.has(flagAltFmt) {
+				s.fmtStr(arg, token.flags, int(token.width), int(token.prec))
+				return
+			}
+		case verbHex:
+			if token.width == absentValue {
+				s.fmtHex(arg, token.flags, int(token.prec))
+				return
+			}
+		}
+	}
+
+	token.format(s.buf, args)
+
+	s.flushIfFull()
+}
+
+// SEE fmtStr()
+//go:nosplit
+func (s *streamer) fmtStr(str string, flags flags, width, prec int) {
+
+	str, n := truncateStringCount(str, prec)
+
+	if width <= 0 /* implies `width == absentValue` */ {
+		s.WriteString(str)
+		return
+	}
+
+	if n == absentValue {
+		n = runeCountInString(str)
+	}
+
+	left, right := flags.padSides(width - n)
+
+	// either left padding ...
+	s.padding(left, flags)
+
+	s.WriteString(str)
+
+	// ... or right padding (or both for centered value)
+	s.padding(right, flags)
+}
+
+// padding writes `n` padding bytes by chunks, so huge width doesn't grow the buffer
+// SEE writePadding()
+//go:nosplit
+func (s *streamer) padding(n int, flags flags) {
+
+	for (n > 0) && (s.err == nil) {
+
+		// NOTE m <= 0 means buffer is already full, so only flush below is needed
+		if m := streamFlushThreshold - s.buf.Len(); m > 0 {
+
+			if m > n {
+				m = n
+			}
+
+			writePadding(s.buf, m, flags)
+
+			n -= m
+		}
+
+		s.flushIfFull()
+	}
+}
+
+// SEE fmtHex()
+// NOTE only unpadded values, because padding requires the whole encoded width
+//go:nosplit
+func (s *streamer) fmtHex(str string, flags flags, prec int) {
+
+	// Set length to not process more bytes than the precision demands.
+	if (prec != absentValue) && (prec < len(str)) {
+		str = str[:prec]
+	}
+
+	for first := true; (str != "") && (s.err == nil); first = false {
+
+		chunk := str
+
+		if len(chunk) > streamHexChunkSize {
+			chunk = chunk[:streamHexChunkSize]
+		}
+
+		str = str[len(chunk):]
+
+		if !first {
+			if flags.has(flagWithSpace) {
+				// chunks should be separated with a space like elements inside chunk
+				s.buf.WriteByte(charSpace)
+			} else {
+				// leading 0x or 0X is added only once for the whole string
+				flags &^= flagAltFmt
+			}
+		}
+
+		fmtHex(s.buf, chunk, flags, absentValue, absentValue)
+
+		s.flushIfFull()
+	}
+}
+
+// Fstream is the streaming version of (*xfmt).Fprint, which writes result to `w` by parts
+func (fmt *xfmt) Fstream(w io.Writer, args []string) (n int, err error) {
+
+	s := newStreamer(w, &fmtprintbufpool)
+
+	for i := 0; (i < len(fmt.tokens)) && (s.err == nil); i++ {
+
+		if fmt.tokens[i].verb == verbGroup {
+			i += skipGroup(fmt.tokens, i, args)
+			continue
+		}
+
+		s.token(&fmt.tokens[i], args)
+	}
+
+	writeExtra(&s, args, fmt.args)
+
+	return s.finish()
+}
+
+// streaming version of fprint()
+//go:nosplit
+func fstream(w io.Writer, ln bool, str ...string) (n int, err error) {
+
+	s := newStreamer(w, &stdprintbufpool)
+
+	for i := 0; (i < len(str)) && (s.err == nil); i++ {
+
+		if ln && (i > 0) {
+			s.buf.WriteByte(Space)
+		}
+
+		s.WriteString(str[i])
+	}
+
+	if ln {
+		s.buf.WriteByte(LF)
+	}
+
+	return s.finish()
+}
+
+// total len of all strings in `s`
+//go:nosplit
+func sumLen(s []string) (n int) {
+
+	for i := range s {
+		n += len(s[i])
+	}
+
+	return n
+}
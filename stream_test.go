@@ -0,0 +1,252 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// writer without io.StringWriter impl, which tracks all writes
+type streamTestWriter struct {
+	buf    bytes.Buffer
+	writes int
+	maxLen int
+	limit  int // write error after `limit` bytes; 0 means no limit
+}
+
+var errStreamTestWriterLimit = errors.New("stream test writer limit exceeded")
+
+func (w *streamTestWriter) Write(p []byte) (n int, err error) {
+
+	w.writes++
+
+	if len(p) > w.maxLen {
+		w.maxLen = len(p)
+	}
+
+	if (w.limit > 0) && (w.buf.Len()+len(p) > w.limit) {
+		n, _ = w.buf.Write(p[:w.limit-w.buf.Len()])
+		return n, errStreamTestWriterLimit
+	}
+
+	return w.buf.Write(p)
+}
+
+var (
+	tstc1argbig1 = strings.Repeat("big `arg` with utf8 ☭ and \"quotes\" ", 1<<10)
+	tstc1argbig2 = strings.Repeat("0123456789abcdef", 1<<12)
+	tstc1argbig3 = strings.Repeat("x", streamDirectThreshold-1)
+)
+
+type streamTestCase struct {
+	format string
+	args   SL
+}
+
+var streamTestCases1 = [...]streamTestCase{
+	{"%s", SL{tstc1argbig1}},
+	{"head %s mid %s tail", SL{tstc1argbig1, tstc1argbig2}},
+	{"%100000s|%-100000s|%.7s|%10.3s", SL{tstc1argbig3, tstc1argbig1, tstc1argbig2, tstc1argbig1}},
+	{"%x|%X|% x|%#x|% #X|%.5000x|%20000x", SL{tstc1argbig2, tstc1argbig1, tstc1argbig2, tstc1argbig1, tstc1argbig2, tstc1argbig1, tstc1argbig3}},
+	{"%q %#q %% %[1]s %*s %z", SL{tstc1argbig1, tstc1argbig2, tstc1argbig3, tstc1argbig3}},
+	{"%s %s", SL{tstc1argbig3, tstc1argbig3, tstc1argbig2, tstc1argbig1}},
+	{"%s %s %s", SL{tstc1argbig2}},
+}
+
+// go test -count=1 -v -run "^TestStreamFprintf$"
+func TestStreamFprintf(t *testing.T) {
+
+	for i := 0; i < len(streamTestCases1); i++ {
+
+		tcase := &streamTestCases1[i]
+
+		want := Sprintf(tcase.format, tcase.args...)
+
+		// io.StringWriter
+		var sw bytes.Buffer
+
+		if n, err := Fprintf(&sw, tcase.format, tcase.args...); err != nil || n != len(want) {
+			t.Fatalf("%d: Fprintf(%q) to StringWriter unexpected retval: n = %d (want %d), err = %v", i, tcase.format, n, len(want), err)
+		}
+
+		if got := sw.String(); got != want {
+			t.Fatalf("%d: Fprintf(%q) to StringWriter result mismatch (len want %d, got %d)", i, tcase.format, len(want), len(got))
+		}
+
+		// plain io.Writer
+		var w streamTestWriter
+
+		if n, err := Fprintf(&w, tcase.format, tcase.args...); err != nil || n != len(want) {
+			t.Fatalf("%d: Fprintf(%q) to Writer unexpected retval: n = %d (want %d), err = %v", i, tcase.format, n, len(want), err)
+		}
+
+		if got := w.buf.String(); got != want {
+			t.Fatalf("%d: Fprintf(%q) to Writer result mismatch (len want %d, got %d)", i, tcase.format, len(want), len(got))
+		}
+
+		if w.writes < 2 {
+			t.Fatalf("%d: Fprintf(%q) large result has been written without streaming", i, tcase.format)
+		}
+	}
+}
+
+// go test -count=1 -v -run "^TestStreamChunkedFlushes$"
+func TestStreamChunkedFlushes(t *testing.T) {
+
+	var w streamTestWriter
+
+	want := fmt.Sprintf("%s|% #x", tstc1argbig2, tstc1argbig2)
+
+	if _, err := Fprintf(&w, "%s|% #x", tstc1argbig2, tstc1argbig2); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := w.buf.String(); got != want {
+		t.Fatalf("result mismatch (len want %d, got %d)", len(want), len(got))
+	}
+
+	// single chunk may exceed flush threshold by the size of one formatted hex chunk
+	if max := streamFlushThreshold + 5*streamHexChunkSize; w.maxLen > max {
+		t.Fatalf("too large single write: %d > %d", w.maxLen, max)
+	}
+}
+
+// go test -count=1 -v -run "^TestStreamHugePadding$"
+func TestStreamHugePadding(t *testing.T) {
+
+	for _, c := range [...]struct {
+		parse  func(format string) xfmt
+		format string
+		args   SL
+	}{
+		{parseFormat, "%1000000s|%-1000000s|", SL{"x", "y"}},
+		{parseFormat, "%1000000s|%-1000000s|", SL{tstc1argbig1, tstc1argbig2}},
+		// centered value has padding on both sides
+		{parseBraceFormat, "{:^1000000}|{:*^1000000}|", SL{tstc1argbig3, "z"}},
+	} {
+
+		var w streamTestWriter
+
+		xfmt := c.parse(c.format)
+
+		want := xfmt.Sprint(c.args)
+
+		if n, err := xfmt.Fstream(&w, c.args); (err != nil) || (n != len(want)) {
+			t.Fatalf("%q: unexpected retval: n = %d (want %d), err = %v", c.format, n, len(want), err)
+		}
+
+		if got := w.buf.String(); got != want {
+			t.Fatalf("%q: result mismatch (len want %d, got %d)", c.format, len(want), len(got))
+		}
+
+		// padding is flushed by chunks, so only small strings may be written together with it
+		if max := streamFlushThreshold + streamDirectThreshold; w.maxLen > max {
+			t.Fatalf("%q: too large single write: %d > %d", c.format, w.maxLen, max)
+		}
+	}
+}
+
+// go test -count=1 -v -run "^TestStreamSmallSingleWrite$"
+func TestStreamSmallSingleWrite(t *testing.T) {
+
+	var w streamTestWriter
+
+	if _, err := Fprintf(&w, "%s %q %x", tstc1argbig3, tstc1argbig3, "xfmt"); err != nil {
+		t.Fatal(err)
+	}
+
+	if w.writes != 1 {
+		t.Fatalf("small result should be written by single write, got %d writes", w.writes)
+	}
+}
+
+// go test -count=1 -v -run "^TestStreamWriteError$"
+func TestStreamWriteError(t *testing.T) {
+
+	const limit = streamFlushThreshold + 123
+
+	w := streamTestWriter{limit: limit}
+
+	n, err := Fprintf(&w, "%s %s", tstc1argbig1, tstc1argbig2)
+
+	if err != errStreamTestWriterLimit {
+		t.Fatalf("unexpected error: want %v, got %v", errStreamTestWriterLimit, err)
+	}
+
+	if n != limit {
+		t.Fatalf("written bytes mismatch: want %d, got %d", limit, n)
+	}
+
+	if want := Sprintf("%s %s", tstc1argbig1, tstc1argbig2)[:limit]; w.buf.String() != want {
+		t.Fatal("written data mismatch")
+	}
+}
+
+// go test -count=1 -v -run "^TestStreamFprint$"
+func TestStreamFprint(t *testing.T) {
+
+	args := []string{tstc1argbig1, tstc1argbig2, "", tstc1argbig3}
+
+	for _, ln := range [...]bool{false, true} {
+
+		var (
+			w    streamTestWriter
+			want string
+			n    int
+			err  error
+		)
+
+		if ln {
+			want = Sprintln(args...)
+			n, err = Fprintln(&w, args...)
+		} else {
+			want = Sprint(args...)
+			n, err = Fprint(&w, args...)
+		}
+
+		if err != nil || n != len(want) {
+			t.Fatalf("ln %t: unexpected retval: n = %d (want %d), err = %v", ln, n, len(want), err)
+		}
+
+		if w.buf.String() != want {
+			t.Fatalf("ln %t: result mismatch", ln)
+		}
+
+		if w.writes < 2 {
+			t.Fatalf("ln %t: large result has been written without streaming", ln)
+		}
+	}
+}
+
+// go test -bench "^BenchmarkStreamFprintf$" -run "^$" -benchmem
+func BenchmarkStreamFprintf(b *testing.B) {
+
+	var w streamTestWriter
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		w.buf.Reset()
+		_, _ = Fprintf(&w, "head %s mid %x tail", tstc1argbig2, tstc1argbig1)
+	}
+}
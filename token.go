@@ -34,12 +34,24 @@ import (
  *
  */
 
-type verb uint
+type verb uint8
 
 const (
-	verbCharString = 's'
-	verbCharQuoted = 'q'
-	verbCharHex    = 'x'
+	verbCharString  = 's'
+	verbCharQuoted  = 'q'
+	verbCharHex     = 'x'
+	verbCharDecimal = 'd'
+	verbCharOctal   = 'o'
+	verbCharBinary  = 'b'
+	verbCharExp     = 'e'
+	verbCharFloat   = 'f'
+	verbCharGeneral = 'g'
+	verbCharBool    = 't'
+	verbCharRune    = 'c'
+	verbCharValue   = 'v'
+
+	verbValueString  = string(verbCharValue)
+	verbStringString = string(verbCharString)
 )
 
 const (
@@ -47,18 +59,71 @@ const (
 	verbString             // %s
 	verbQuoted             // %q
 	verbHex                // %x or %X
+	verbValue              // %v, the same as %s for strings
+
+	// verbs of non-string Arg values, SEE arg.go
+	verbDecimal // %d
+	verbOctal   // %o or %O
+	verbBinary  // %b
+	verbExp     // %e or %E
+	verbFloat   // %f or %F
+	verbGeneral // %g or %G
+	verbBool    // %t
+	verbRune    // %c
 
 	// bad verb
 	badVerb
 
+	// plural or select clause of message formats, SEE message.go
+	verbClause
+
+	// conditional group of printf-like formats, SEE group.go
+	verbGroup
+
 	maxVerbs
 )
 
-// TODO? [26]verb instead of map[rune]verb (26 is count of en letters --> F(v - 'a'))?
-var verbMap = map[rune]verb{
-	verbCharString: verbString,
-	verbCharQuoted: verbQuoted,
-	verbCharHex:    verbHex,
+// mark of uppercased verb char inside charVerbs table
+const verbUpper verb = 1 << 7
+
+// charVerbs is ascii lookup table of verbs: known verb for verb chars, `badVerb` for all other en letters and
+// `verbNone` for non-letters; uppercased letters are additionally marked by `verbUpper`
+var charVerbs = makeCharVerbs()
+
+//go:nosplit
+func makeCharVerbs() (table [utf8.RuneSelf]verb) {
+
+	for c := 'a'; c <= 'z'; c++ {
+		table[c] = badVerb
+		table[c-'a'+'A'] = badVerb | verbUpper
+	}
+
+	for c, verb := range [...]verb{
+		verbCharString:  verbString,
+		verbCharQuoted:  verbQuoted,
+		verbCharHex:     verbHex,
+		verbCharDecimal: verbDecimal,
+		verbCharOctal:   verbOctal,
+		verbCharBinary:  verbBinary,
+		verbCharExp:     verbExp,
+		verbCharFloat:   verbFloat,
+		verbCharGeneral: verbGeneral,
+		verbCharBool:    verbBool,
+		verbCharRune:    verbRune,
+		verbCharValue:   verbValue,
+	} {
+		if verb != verbNone {
+			table[c] = verb
+		}
+	}
+
+	// only these verbs have uppercased versions (`%D`, `%B` and `%T` are bad verbs like in std `fmt`)
+	for _, c := range [...]byte{verbCharString, verbCharQuoted, verbCharHex, verbCharOctal, verbCharExp,
+		verbCharFloat, verbCharGeneral} {
+		table[c-'a'+'A'] = table[c] | verbUpper
+	}
+
+	return table
 }
 
 /* DOC: format flags:
@@ -82,7 +147,7 @@ var verbMap = map[rune]verb{
  * and %d behave identically.
  */
 
-type flags uint
+type flags uint16
 
 const (
 	flagCharPlus  = '+'
@@ -107,6 +172,8 @@ const (
 	flagIndirectWidth
 	// - precision is indirect (".[n]")
 	flagIndirectPrec
+	// - value is centered inside the width (brace formats "{:^10}", SEE brace.go)
+	flagCenter
 
 	flagsMask flags = (1 << iota) - 1
 
@@ -121,8 +188,21 @@ const (
 	flagPadZeros  = flagZero
 )
 
-// TODO
-var charFlags = map[byte]flags{}
+// explicit ascii padding char (brace formats "{:*<10}") is kept in the high bits of flags above all of the flags,
+// zero value means default padding (either spaces or zeros)
+const (
+	fillShift = 9 // bits count of flagsMask
+	fillMask  = ^flagsMask
+)
+
+// charFlags is ascii lookup table of flags, `flagNone` for non-flag chars
+var charFlags = [utf8.RuneSelf]flags{
+	flagCharPlus:  flagPlus,
+	flagCharMinus: flagMinus,
+	flagCharSharp: flagSharp,
+	flagCharSpace: flagSpace,
+	flagCharZero:  flagZero,
+}
 
 // inlined
 //go:nosplit
@@ -137,6 +217,48 @@ func (flags flags) omit(flag flags) bool {
 	return (flags & flag) == 0
 }
 
+// withFill returns flags with explicit ascii padding char c
+// inlined
+//go:nosplit
+func (f flags) withFill(c byte) flags {
+	return (f &^ fillMask) | (flags(c) << fillShift)
+}
+
+// padByte returns padding char: explicit one if any, otherwise zero for flagPadZeros or space
+// inlined
+//go:nosplit
+func (flags flags) padByte() byte {
+
+	if c := byte(flags >> fillShift); c != 0 {
+		return c
+	}
+
+	if flags.has(flagPadZeros) {
+		return charZero
+	}
+
+	return charSpace
+}
+
+// padSides splits n padding chars between left and right sides of the value according to alignment flags
+// inlined
+//go:nosplit
+func (flags flags) padSides(n int) (left, right int) {
+
+	switch {
+	case flags.has(flagCenter):
+		// extra char goes to the right like in python
+		left = n >> 1
+		right = n - left
+	case flags.has(flagPadRight):
+		right = n
+	default:
+		left = n
+	}
+
+	return left, right
+}
+
 const (
 	maxNumBitSize = 20 // required number of bits to store src/fmt/print.go::tooLarge()::max === 1e6
 	maxNum        = 1<<maxNumBitSize - 1
@@ -181,13 +303,15 @@ const (
  * ArgNum = '[' UINT ']'
  */
 
+// NOTE compact representation: 32 bytes on x64 instead of 56 with `int` fields (fields are ordered by their
+//      alignment to avoid paddings); width, prec and arg num values are limited by maxNum anyway
 type token struct {
-	verb  verb
 	value string
+	width int32
+	prec  int32  // precision
+	arg   uint32 // arg number, handle notation [n] immediately before the verb; unsigned automagically helps BCE
 	flags flags
-	width int
-	prec  int  // precision
-	arg   uint // arg number, handle notation [n] immediately before the verb; uint automagically helps BCE
+	verb  verb
 }
 
 // pseudoflag `no value defined` for width and prec
@@ -214,6 +338,7 @@ var verbFmtFuncsTable = [...]fmtFn{ // fmtFuncsTable{
 	verbString: fmtStr,
 	verbQuoted: fmtQuot,
 	verbHex:    fmtHex,
+	verbValue:  fmtValue,
 }
 
 // inlined
@@ -257,7 +382,7 @@ func (token *token) badArgNum(buf *buffer /* *strings.Builder */) {
 	// PPSL: use Grow before multiple writes to minimize memallocs
 
 	buf.WriteString(percentBangString)
-	buf.WriteString(token.value)
+	buf.WriteString(token.verbString())
 	buf.WriteString(badIndexString)
 }
 
@@ -268,7 +393,7 @@ func (token *token) missingArg(buf *buffer /* *strings.Builder */) {
 	// PPSL: use Grow before multiple writes to minimize memallocs
 
 	buf.WriteString(percentBangString)
-	buf.WriteString(token.value)
+	buf.WriteString(token.verbString())
 	buf.WriteString(missingString)
 }
 
@@ -279,7 +404,7 @@ func (token *token) badVerb(buf *buffer /* *strings.Builder */, arg string) {
 	// PPSL: use Grow before multiple writes to minimize memallocs
 
 	buf.WriteString(percentBangString)
-	buf.WriteString(token.value)
+	buf.WriteString(token.verbString())
 
 	buf.WriteByte(charLeftParens) // (
 
@@ -318,6 +443,8 @@ func (token *token) fmtString(buf *buffer /* *strings.Builder */, arg string, fl
 		return fmtQuot(buf, arg, flags, width, prec)
 	case verbHex:
 		return fmtHex(buf, arg, flags, width, prec)
+	case verbValue:
+		return fmtValue(buf, arg, flags, width, prec)
 	}
 
 	// impossible situation
@@ -352,7 +479,7 @@ func (token *token) format(buf *buffer /* *strings.Builder */, args []string) bo
 	// second part of the original `goodArgNum` check
 	badArgNum := false
 
-	width := token.width
+	width := int(token.width)
 
 	// is width indirect?
 	if flags.has(flagIndirectWidth) {
@@ -371,7 +498,7 @@ func (token *token) format(buf *buffer /* *strings.Builder */, args []string) bo
 		width = absentValue
 	}
 
-	prec := token.prec
+	prec := int(token.prec)
 
 	// is precision indirect?
 	if flags.has(flagIndirectPrec) {
@@ -400,13 +527,13 @@ func (token *token) format(buf *buffer /* *strings.Builder */, args []string) bo
 	// here width and prec are proper values
 
 	// check that appropriate arg exists in args
-	if token.arg >= uint(len(args)) {
+	if uint(token.arg) >= uint(len(args)) {
 		token.missingArg(buf)
 		return false
 	}
 
-	// pick our arg
-	arg := args[token.arg]
+	// pick our arg, empty one is replaced by default value
+	arg := token.withDefault(args[token.arg])
 
 	// first check cases which mean `error`
 	if token.verb == badVerb {
@@ -425,11 +552,23 @@ func (token *token) format(buf *buffer /* *strings.Builder */, args []string) bo
 // SEE src/fmt/format.go::(*fmt).fmtS()
 //go:nosplit
 func fmtStr(buf *buffer /* *strings.Builder */, s string, flags flags, width, prec int) bool {
-	s = truncateString(s, prec)
-	padString(buf, s, flags, width)
+	s, n := truncateStringCount(s, prec)
+	padStringCount(buf, s, n, flags, width)
 	return true
 }
 
+// SEE src/fmt/print.go::(*pp).fmtString() `case 'v'`
+//go:nosplit
+func fmtValue(buf *buffer /* *strings.Builder */, s string, flags flags, width, prec int) bool {
+
+	// Go-syntax %#v is always double-quoted string
+	if flags.has(flagAltFmt) {
+		return fmtQuot(buf, s, flags&^(flagAltFmt|flagPlus), width, prec)
+	}
+
+	return fmtStr(buf, s, flags, width, prec)
+}
+
 // SEE src/fmt/format.go::(*fmt).fmtQ()
 //go:nosplit
 func fmtQuot(buf *buffer /* *strings.Builder */, s string, flags flags, width, prec int) bool {
@@ -446,16 +585,10 @@ func fmtQuot(buf *buffer /* *strings.Builder */, s string, flags flags, width, p
 		return true
 	}
 
-	b := buf.TempBuf()
-
 	// check ascii-only flag
-	if flags.has(flagAsciiOnly) {
-		b = strconv.AppendQuoteToASCII(b, s)
-	} else {
-		b = strconv.AppendQuote(b, s)
-	}
+	b, n := appendQuote(buf.TempBuf(), s, flags.has(flagAsciiOnly))
 
-	pad(buf, b, flags, width)
+	padCount(buf, b, n, flags, width)
 
 	return true
 }
@@ -505,86 +638,46 @@ func fmtHex(buf *buffer /* *strings.Builder */, s string, flags flags, width, pr
 		w += 2
 	}
 
-	rpad := flags.has(flagPadRight)
+	left, right := 0, 0
 
-	// Handle padding to the left.
-	if (width != absentValue) && !rpad && (width > w) {
-		writePadding(buf, width-w, flags)
+	if width > w /* implies `width != absentValue` */ {
+		left, right = flags.padSides(width - w)
 	}
 
+	// Handle padding to the left.
+	writePadding(buf, left, flags)
+
 	// select digits set - lowercased or uppercased
 
-	digits := ldigits
+	digits, pairs := ldigits, &lhexPairs
 
 	if flags.has(flagUpperVerb) {
-		digits = udigits
+		digits, pairs = udigits, &uhexPairs
 	}
 
-	// TODO Write the encoding directly into the output buffer.
-	//      Additionally *strings.Builder impl has too many redundant checks (every buf.Write has copyCheck and
-	//      implicit growslice check in append)
-
-	// prepare buffer to minimize memallocs
+	// write the encoding directly into the output buffer
 	raw := buf.Advance(w)
 
-	j := uint(0)
+	// helps BCE below
+	s = s[:sz]
 
-	if altFmt {
+	switch {
+	case withSpace && altFmt:
+		hexEncodeAltSpaced(raw, s, pairs, digits[16])
+	case withSpace:
+		hexEncodeSpaced(raw, s, pairs)
+	case altFmt:
 		// Add leading 0x or 0X.
-
-		//buf.WriteByte(charZero)
-		//buf.WriteByte(digits[16])
-
 		raw[0] = charZero
 		raw[1] = digits[16]
 
-		j = 2
-	}
-
-	// helps BCE below
-	s = s[:sz]
-
-	for i := 0; i < len(s) && (j < uint(len(raw))); i++ {
-
-		// write inter-elements values if any
-		if withSpace && (i > 0) {
-
-			// Separate elements with a space.
-
-			//buf.WriteByte(charSpace)
-
-			raw[j] = charSpace
-			j++
-
-			if altFmt {
-				// Add leading 0x or 0X for each element.
-
-				//buf.WriteByte(charZero)
-				//buf.WriteByte(digits[16])
-
-				raw[j] = charZero
-				raw[j+1] = digits[16]
-
-				j += 2
-			}
-		}
-
-		// write byte as hex
-		c := s[i]
-
-		//buf.WriteByte(digits[c>>4])   // high
-		//buf.WriteByte(digits[c&0x0F]) // low
-
-		raw[j] = digits[c>>4]     // high
-		raw[j+1] = digits[c&0x0F] // low
-
-		j += 2
+		hexEncode(raw[2:], s, pairs)
+	default:
+		hexEncode(raw, s, pairs)
 	}
 
 	// Handle padding to the right.
-	if (width != absentValue) && rpad && (width > w) {
-		writePadding(buf, width-w, flags)
-	}
+	writePadding(buf, right, flags)
 
 	return true
 }
@@ -604,61 +697,87 @@ func truncateString(s string, prec int) string {
 	return s
 }
 
+// truncateStringCount is truncateString, which also returns amount of runes in the result if it is known
+// without counting (the string has been actually truncated), or absentValue otherwise
+//go:nosplit
+func truncateStringCount(s string, prec int) (string, int) {
+
+	// string with no more bytes than precision is never truncated
+	if (prec != absentValue) && (prec < len(s)) {
+
+		if t := truncateTail(s, prec); len(t) < len(s) {
+			return t, prec
+		}
+	}
+
+	return s, absentValue
+}
+
 // padString appends s to buf, padded on left (!flagPadRight) or right (flagPadRight).
 // DOC: For most values, width is the minimum number of runes to output, padding the formatted form with spaces if necessary.
 // SEE src/fmt/format.go::(*fmt).padString()
+// inlined
 //go:nosplit
 func padString(buf *buffer /* *strings.Builder */, s string, flags flags, width int) {
+	padStringCount(buf, s, absentValue, flags, width)
+}
+
+// padStringCount is padString with already known amount of runes `n` in s (absentValue if unknown)
+//go:nosplit
+func padStringCount(buf *buffer /* *strings.Builder */, s string, n int, flags flags, width int) {
 
 	if width <= 0 /* implies `width == absentValue` */ {
 		buf.WriteString(s)
 		return
 	}
 
-	// DOC: width := f.wid - utf8.RuneCountInString(s)
-	width -= utf8.RuneCountInString(s)
+	if n == absentValue {
+		n = runeCountInString(s)
+	}
 
-	rpad := flags.has(flagPadRight)
+	// DOC: width := f.wid - utf8.RuneCountInString(s)
+	left, right := flags.padSides(width - n)
 
 	// either left padding ...
-	if !rpad && (width > 0) {
-		writePadding(buf, width, flags)
-	}
+	writePadding(buf, left, flags)
 
 	buf.WriteString(s)
 
-	// ... or right padding
-	if rpad && (width > 0) {
-		writePadding(buf, width, flags)
-	}
+	// ... or right padding (or both for centered value)
+	writePadding(buf, right, flags)
 }
 
 // pad appends b to f.buf, padded on left (!f.minus) or right (f.minus).
 // DOC: see padString above
+// inlined
 //go:nosplit
 func pad(buf *buffer /* *strings.Builder */, raw []byte, flags flags, width int) {
+	padCount(buf, raw, absentValue, flags, width)
+}
+
+// padCount is pad with already known amount of runes `n` in raw (absentValue if unknown)
+//go:nosplit
+func padCount(buf *buffer /* *strings.Builder */, raw []byte, n int, flags flags, width int) {
 
 	if width <= 0 /* implies `width == absentValue` */ {
 		buf.Write(raw)
 		return
 	}
 
-	// DOC: width := f.wid - utf8.RuneCount(s)
-	width -= utf8.RuneCount(raw)
+	if n == absentValue {
+		n = runeCountInString(bytesString(raw))
+	}
 
-	rpad := flags.has(flagPadRight)
+	// DOC: width := f.wid - utf8.RuneCount(s)
+	left, right := flags.padSides(width - n)
 
 	// either left padding ...
-	if !rpad && (width > 0) {
-		writePadding(buf, width, flags)
-	}
+	writePadding(buf, left, flags)
 
 	buf.Write(raw)
 
-	// ... or right padding
-	if rpad && (width > 0) {
-		writePadding(buf, width, flags)
-	}
+	// ... or right padding (or both for centered value)
+	writePadding(buf, right, flags)
 }
 
 //go:nosplit
@@ -669,11 +788,7 @@ func writePadding(buf *buffer /* *strings.Builder */, n int, flags flags) {
 	}
 
 	// Decide which byte the padding should be filled with.
-	padByte := byte(charSpace)
-
-	if flags.has(flagPadZeros) {
-		padByte = charZero
-	}
+	padByte := flags.padByte()
 
 	// Make enough room for padding.
 	tail := buf.Advance(n)
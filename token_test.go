@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"unicode/utf8"
 )
 
 type tokenTestCase struct {
@@ -639,3 +640,53 @@ func TestTokenCases(t *testing.T) {
 		t.Fatalf("some tests finished with errors: %d of %d", nerrs, sz)
 	}
 }
+
+// go test -count=1 -v -run "^TestRuneCountFastPaths$"
+func TestRuneCountFastPaths(t *testing.T) {
+
+	sources := [...]string{
+		"",
+		"a",
+		"short",
+		"exactly8",
+		"ascii string longer than a machine word",
+		"ascii prefix longer than word then utf8: Привет, мир",
+		"Привет",
+		"mixed ☺ utf8 ☻ and ascii",
+		"tab\tquote\"backslash\\ and \x7f del",
+		"bad utf8 \xff\xfe sequence",
+		"日本語日本語日本語",
+	}
+
+	formats := [...]string{"%10.5s", "%-10.5s", "%.20s", "%30s", "%q", "%+q", "%#q", "%40.7q", "%-+40.12q", "%#30q", "%.0q"}
+
+	for _, s := range sources {
+
+		if want, got := utf8.RuneCountInString(s), runeCountInString(s); got != want {
+			t.Errorf("%q: rune count mismatch: want %d, got %d", s, want, got)
+		}
+
+		for n := 0; n <= len(s)+1; n++ {
+
+			want := s
+
+			if runes := []rune(s); n < len(runes) {
+				want = string(runes[:n])
+			}
+
+			// rune conversion replaces bad utf8 with RuneError, so compare runes-based results only for valid utf8
+			if got := truncateTail(s, n); utf8.ValidString(s) && (got != want) {
+				t.Errorf("%q: truncateTail(%d) mismatch: want %q, got %q", s, n, want, got)
+			}
+		}
+
+		for _, format := range formats {
+
+			x := parseFormat(format)
+
+			if want, got := fmt.Sprintf(format, s), x.Sprint([]string{s}); got != want {
+				t.Errorf("%q %q: mismatch result: want <%s>, got <%s>", format, s, want, got)
+			}
+		}
+	}
+}
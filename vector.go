@@ -0,0 +1,234 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"io"
+	"net"
+	"sync"
+)
+
+// INFO: vectored writes
+//      When writing to sockets or files, copying big `%s` args into the pooled buffer is wasted work, so vectorizer
+//      builds net.Buffers of buffered parts (small raw const values, paddings, quoted and hex fragments) and zero-copy
+//      references to unmodified large raw const values and `%s` args, and then writes all of them at once by
+//      net.Buffers.WriteTo, which uses `writev` syscall for *net.TCPConn, *net.UnixConn and others. net.Buffers.WriteTo
+//      calls w.Write for every buffer of other writers, so vectors are built only for net.Conn writers, while the
+//      result for other writers (files, pipes, bytes.Buffer) is concatenated into single buffer like Fprintf does
+
+const (
+	// estimated size of the result below which single buffer write is used
+	vecThreshold = 4 << 10 // 4k
+	// strings of at least this size are referenced instead of being copied into buffer
+	vecRefThreshold = 1 << 9 // 512
+)
+
+// part of the result: either zero-copy reference to string or range of the buffer
+type vecSegment struct {
+	ref        string // zero-copy reference if not empty
+	start, end int    // buffer range, valid only for empty `ref`
+}
+
+type vectorizer struct {
+	buf  *buffer
+	segs []vecSegment
+	bufs [][]byte // reusable backing storage for net.Buffers
+	out  net.Buffers
+	mark int      // start of the current unclosed buffer range
+}
+
+var vectorizerpool = sync.Pool{
+	New: func() interface{} {
+		return new(vectorizer)
+	},
+}
+
+//go:nosplit
+func getVectorizer() *vectorizer {
+
+	v := vectorizerpool.Get().(*vectorizer)
+
+	v.buf = fmtprintbufpool.Get()
+
+	return v
+}
+
+//go:nosplit
+func (v *vectorizer) free() {
+
+	// don't hold references to args and buffer inside pool
+	for i := range v.segs {
+		v.segs[i] = vecSegment{}
+	}
+
+	for i := range v.bufs {
+		v.bufs[i] = nil
+	}
+
+	v.segs, v.bufs, v.mark = v.segs[:0], v.bufs[:0], 0
+
+	v.buf.Free()
+	v.buf = nil
+
+	vectorizerpool.Put(v)
+}
+
+// closes current buffer range, if it isn't empty
+// inlined
+//go:nosplit
+func (v *vectorizer) closeRange() {
+	if end := v.buf.Len(); end > v.mark {
+		v.segs = append(v.segs, vecSegment{start: v.mark, end: end})
+		v.mark = end
+	}
+}
+
+//go:nosplit
+func (v *vectorizer) WriteString(s string) {
+
+	// small strings are cheaper to copy than to pass as separate iovec
+	if len(s) < vecRefThreshold {
+		v.buf.WriteString(s)
+		return
+	}
+
+	v.closeRange()
+
+	v.segs = append(v.segs, vecSegment{ref: s})
+}
+
+// SEE (*token).format() and (*streamer).token()
+//go:nosplit
+func (v *vectorizer) token(token *token, args []string) {
+
+	if token.verb == verbNone {
+		v.WriteString(token.value)
+		return
+	}
+
+	// only unmodified (but maybe truncated and padded) `%s` args are referenced, all other cases go to the buffer
+	if (token.verb == verbString) && !token.flags.has(flagIndirectWidth|flagIndirectPrec) &&
+		(uint(token.arg) < uint(len(args))) {
+
+		v.fmtStr(token.withDefault(args[token.arg]), token.flags, int(token.width), int(token.prec))
+
+		return
+	}
+
+	token.format(v.buf, args)
+}
+
+// SEE fmtStr()
+//go:nosplit
+func (v *vectorizer) fmtStr(s string, flags flags, width, prec int) {
+
+	// truncated string is still a reference to the same data
+	s, n := truncateStringCount(s, prec)
+
+	if width <= 0 /* implies `width == absentValue` */ {
+		v.WriteString(s)
+		return
+	}
+
+	if n == absentValue {
+		n = runeCountInString(s)
+	}
+
+	left, right := flags.padSides(width - n)
+
+	// either left padding ...
+	writePadding(v.buf, left, flags)
+
+	v.WriteString(s)
+
+	// ... or right padding (or both for centered value)
+	writePadding(v.buf, right, flags)
+}
+
+// WARN buffer ranges are resolved only here after all writes, because buffer may be reallocated during writes
+//go:nosplit
+func (v *vectorizer) writeTo(w io.Writer) (n int64, err error) {
+
+	v.closeRange()
+
+	b := v.buf.Bytes()
+
+	for i := range v.segs {
+
+		seg := &v.segs[i]
+
+		if seg.ref != "" {
+			v.bufs = append(v.bufs, stringBytes(seg.ref))
+		} else {
+			v.bufs = append(v.bufs, b[seg.start:seg.end])
+		}
+	}
+
+	// NOTE WriteTo consumes (reslices) its receiver, so separate copy of the slice header is used
+	// NOTE field instead of local var to avoid heap escape of net.Buffers slice header
+	v.out = v.bufs
+
+	n, err = v.out.WriteTo(w)
+
+	v.out = nil
+
+	return n, err
+}
+
+// Fvec is the vectored writes version of (*xfmt).Fprint
+func (fmt *xfmt) Fvec(w io.Writer, args []string) (n int, err error) {
+
+	// small messages and writers without `writev` support still use single buffer write
+	if fmt.minSize+sumLen(args) < vecThreshold {
+		return fmt.Fprint(w, args)
+	}
+
+	if _, ok := w.(net.Conn); !ok {
+		return fmt.Fprint(w, args)
+	}
+
+	v := getVectorizer()
+
+	for i := 0; i < len(fmt.tokens); i++ {
+
+		if fmt.tokens[i].verb == verbGroup {
+			i += skipGroup(fmt.tokens, i, args)
+			continue
+		}
+
+		v.token(&fmt.tokens[i], args)
+	}
+
+	writeExtra(v, args, fmt.args)
+
+	// WARN write BEFORE return vectorizer and its buffer to pools
+	nn, err := v.writeTo(w)
+
+	v.free()
+
+	return int(nn), err
+}
+
+// FprintfVec is like Fprintf, but large results for net.Conn writers are written as a vector of buffers (net.Buffers)
+// with zero-copy references to large `%s` args, so `writev` is used for conns supporting it (*net.TCPConn,
+// *net.UnixConn); results for other writers are written by single w.Write call like Fprintf does
+func FprintfVec(w io.Writer, format string, args ...string) (n int, err error) {
+	xfmt := forgeXfmt(format)
+	return xfmt.Fvec(w, args)
+}
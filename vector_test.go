@@ -0,0 +1,222 @@
+/**
+ * This file is part of the go-xfmt package (https://github.com/Illirgway/go-xfmt)
+ *
+ * Copyright (c) 2021 Illirgway
+ *
+ * This program is free software: you can redistribute it and/or modify it under the terms of the GNU
+ * General Public License as published by the Free Software Foundation, either version 3 of the License,
+ * or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful, but WITHOUT ANY WARRANTY;
+ * without even the implied warranty of MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.
+ * See the GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License along with this program.
+ * If not, see <https://www.gnu.org/licenses/>.
+ *
+ */
+
+package xfmt
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+// writer which keeps all written slices as is (without copying)
+type vectorTestWriter struct {
+	chunks [][]byte
+}
+
+func (w *vectorTestWriter) Write(p []byte) (n int, err error) {
+	w.chunks = append(w.chunks, p)
+	return len(p), nil
+}
+
+func (w *vectorTestWriter) String() string {
+	return string(bytes.Join(w.chunks, nil))
+}
+
+// true if any of chunks is zero-copy reference to `s`
+func (w *vectorTestWriter) references(s string) bool {
+
+	ptr := *(*unsafe.Pointer)(unsafe.Pointer(&s))
+
+	for _, c := range w.chunks {
+		if (len(c) > 0) && (unsafe.Pointer(&c[0]) == ptr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// net.Conn writer without `writev` support, so net.Buffers.WriteTo writes every buffer separately
+type vectorTestConn struct {
+	net.Conn // nil, only Write is used
+	vectorTestWriter
+}
+
+func (c *vectorTestConn) Write(p []byte) (n int, err error) {
+	return c.vectorTestWriter.Write(p)
+}
+
+var (
+	tvtc1argbig1  = strings.Repeat("vectored `arg` ☭ ", 1<<9)
+	tvtc1argbig2  = strings.Repeat("0123456789abcdef", 1<<8)
+	tvtc1argsmall = "small arg"
+)
+
+var vectorTestCases1 = [...]streamTestCase{
+	{"%s", SL{tvtc1argbig1}},
+	{"head %s mid %s tail %s", SL{tvtc1argbig1, tvtc1argsmall, tvtc1argbig2}},
+	{"%10000s|%-10000s|%.700s|%q|%x|%%", SL{tvtc1argbig1, tvtc1argbig2, tvtc1argbig1, tvtc1argsmall, tvtc1argbig2}},
+	{"%*s %[5]s %z", SL{tvtc1argbig2, tvtc1argbig1, tvtc1argsmall}},
+	{"%s", SL{tvtc1argbig1, tvtc1argbig2}},
+	{"%s %s", SL{tvtc1argsmall}},
+}
+
+// go test -count=1 -v -run "^TestFprintfVec$"
+func TestFprintfVec(t *testing.T) {
+
+	for i := 0; i < len(vectorTestCases1); i++ {
+
+		tcase := &vectorTestCases1[i]
+
+		want := Sprintf(tcase.format, tcase.args...)
+
+		var w vectorTestConn
+
+		if n, err := FprintfVec(&w, tcase.format, tcase.args...); err != nil || n != len(want) {
+			t.Fatalf("%d: FprintfVec(%q) unexpected retval: n = %d (want %d), err = %v", i, tcase.format, n, len(want), err)
+		}
+
+		if got := w.String(); got != want {
+			t.Fatalf("%d: FprintfVec(%q) result mismatch: want <%s>, got <%s>", i, tcase.format, want, got)
+		}
+
+		// writers other than net.Conn get concatenated result
+		var bw bytes.Buffer
+
+		if n, err := FprintfVec(&bw, tcase.format, tcase.args...); err != nil || n != len(want) || bw.String() != want {
+			t.Fatalf("%d: FprintfVec(%q) to buffer mismatch: n = %d (want %d), err = %v", i, tcase.format, n, len(want), err)
+		}
+	}
+}
+
+// go test -count=1 -v -run "^TestFprintfVecZeroCopy$"
+func TestFprintfVecZeroCopy(t *testing.T) {
+
+	var w vectorTestConn
+
+	if _, err := FprintfVec(&w, "head %s mid %20s tail %s", tvtc1argbig1, tvtc1argsmall, tvtc1argbig2); err != nil {
+		t.Fatal(err)
+	}
+
+	if !w.references(tvtc1argbig1) || !w.references(tvtc1argbig2) {
+		t.Fatal("large args have been copied instead of being referenced")
+	}
+
+	// head + arg1 + (mid + padded small arg + tail) + arg2
+	if l := len(w.chunks); l != 4 {
+		t.Fatalf("unexpected count of written chunks: want %d, got %d", 4, l)
+	}
+
+	// small messages should be written at once
+	w = vectorTestConn{}
+
+	if _, err := FprintfVec(&w, "head %s tail", tvtc1argsmall); err != nil {
+		t.Fatal(err)
+	}
+
+	if l := len(w.chunks); l != 1 {
+		t.Fatalf("small message should be written by single write, got %d writes", l)
+	}
+
+	// large messages for writers other than net.Conn are concatenated
+	var ww vectorTestWriter
+
+	if _, err := FprintfVec(&ww, "head %s mid %20s tail %s", tvtc1argbig1, tvtc1argsmall, tvtc1argbig2); err != nil {
+		t.Fatal(err)
+	}
+
+	if l := len(ww.chunks); l != 1 {
+		t.Fatalf("message for non-conn writer should be written by single write, got %d writes", l)
+	}
+}
+
+// go test -count=1 -v -run "^TestFprintfVecTCP$"
+func TestFprintfVecTCP(t *testing.T) {
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		t.Skipf("can't listen loopback: %v", err)
+	}
+
+	defer ln.Close()
+
+	const format = "head %s mid %q tail %s\n"
+
+	want := Sprintf(format, tvtc1argbig1, tvtc1argsmall, tvtc1argbig2)
+
+	done := make(chan string, 1)
+
+	go func() {
+
+		c, err := ln.Accept()
+
+		if err != nil {
+			done <- err.Error()
+			return
+		}
+
+		defer c.Close()
+
+		b, _ := ioutil.ReadAll(c)
+
+		done <- string(b)
+	}()
+
+	c, err := net.Dial("tcp", ln.Addr().String())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := FprintfVec(c, format, tvtc1argbig1, tvtc1argsmall, tvtc1argbig2)
+
+	c.Close()
+
+	if err != nil || n != len(want) {
+		t.Fatalf("unexpected retval: n = %d (want %d), err = %v", n, len(want), err)
+	}
+
+	if got := <-done; got != want {
+		t.Fatalf("received data mismatch (len want %d, got %d)", len(want), len(got))
+	}
+}
+
+// go test -bench "^BenchmarkFprintfVec$" -run "^$" -benchmem
+func BenchmarkFprintfVec(b *testing.B) {
+
+	var w vectorTestConn
+
+	b.Run("vec", func(bb *testing.B) {
+		for i := 0; i < bb.N; i++ {
+			w.chunks = w.chunks[:0]
+			_, _ = FprintfVec(&w, "head %s mid %s tail", tvtc1argbig1, tvtc1argbig2)
+		}
+	})
+
+	b.Run("buf", func(bb *testing.B) {
+		for i := 0; i < bb.N; i++ {
+			w.chunks = w.chunks[:0]
+			_, _ = Fprintf(&w, "head %s mid %s tail", tvtc1argbig1, tvtc1argbig2)
+		}
+	})
+}